@@ -4,6 +4,7 @@ go 1.25.1
 
 require (
 	github.com/peterbourgon/ff/v4 v4.0.0-beta.1
+	golang.org/x/net v0.43.0
 	software.sslmate.com/src/go-pkcs12 v0.6.0
 )
 
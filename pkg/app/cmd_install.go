@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gregtwallace/brother-cert/pkg/printer"
+	"github.com/gregtwallace/brother-cert/pkg/printer/mdns"
 )
 
 // cmdInstallCertAndReset executes a series of commands against a brother printer
@@ -37,6 +38,20 @@ func (app *app) cmdInstallCertAndReset(_ context.Context, args []string) error {
 		useHttp = true
 	}
 
+	// tag output and requests with correlation id?
+	printerOpts := []printer.Option{}
+	if app.config.correlationID != nil && *app.config.correlationID != "" {
+		prefix := fmt.Sprintf("[%s] ", *app.config.correlationID)
+		app.stdLogger.SetPrefix(prefix)
+		app.errLogger.SetPrefix(prefix)
+		printerOpts = append(printerOpts, printer.WithCorrelationID(*app.config.correlationID))
+	}
+
+	// refuse certs valid for too long?
+	if app.config.maxValidity != nil && *app.config.maxValidity > 0 {
+		printerOpts = append(printerOpts, printer.WithMaxValidity(time.Duration(*app.config.maxValidity)*24*time.Hour))
+	}
+
 	// load key and cert
 	keyPem, certPem, err := app.config.keyCertPemCfg.GetPemBytes("main")
 	if err != nil {
@@ -51,7 +66,21 @@ func (app *app) cmdInstallCertAndReset(_ context.Context, args []string) error {
 		UserAgent: fmt.Sprintf("brother-cert/%s (%s; %s)", appVersion, runtime.GOOS, runtime.GOARCH),
 	}
 
-	print, err := printer.NewPrinter(printerCfg)
+	// resolve .local hostname / Bonjour name to the printer's current address
+	if mdns.IsMDNSName(printerCfg.Hostname) {
+		app.stdLogger.Printf("main: resolving %s via mdns ...", printerCfg.Hostname)
+
+		resolveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var mdnsOpts []printer.Option
+		printerCfg, mdnsOpts, err = mdns.PrinterConfig(resolveCtx, printerCfg)
+		cancel()
+		if err != nil {
+			return err
+		}
+		printerOpts = append(printerOpts, mdnsOpts...)
+	}
+
+	print, err := printer.NewPrinter(printerCfg, printerOpts...)
 	if err != nil {
 		return err
 	}
@@ -101,8 +130,12 @@ func (app *app) cmdInstallCertAndReset(_ context.Context, args []string) error {
 	app.stdLogger.Printf("main: new printer cert installed (but not yet activated) (id: %s)", newCertId)
 
 	// activate new key/cert
-	app.stdLogger.Printf("main: activating cert (id: %s) and rebooting... please wait 60 seconds...", newCertId)
-	err = print.SetActiveCert(newCertId)
+	app.stdLogger.Printf("main: activating cert (id: %s) and rebooting...", newCertId)
+	activateOpts := printer.SetActiveCertOptions{}
+	if app.config.dot1x != nil && *app.config.dot1x {
+		activateOpts.Services = []printer.Service{printer.ServiceDot1x}
+	}
+	err = print.SetActiveCertWithOptions(newCertId, activateOpts)
 	if err != nil {
 		return err
 	}
@@ -110,14 +143,17 @@ func (app *app) cmdInstallCertAndReset(_ context.Context, args []string) error {
 	// IF deleting old cert (i.e. old id != 0 (0 cant be deleted, its "Preset"))
 	if oldCertId != "0" {
 		// wait for reboot to finish
-		time.Sleep(60 * time.Second)
-		app.stdLogger.Printf("main: reboot should be complete")
+		err = print.WaitForOnline(context.Background())
+		if err != nil {
+			return err
+		}
+		app.stdLogger.Printf("main: reboot complete")
 
 		// use https now (even if user originally said not to, since cert is installed)
 		printerCfg.UseHttp = false
 
 		// must login again due to the restart
-		print, err = printer.NewPrinter(printerCfg)
+		print, err = printer.NewPrinter(printerCfg, printerOpts...)
 		if err != nil {
 			return errors.New("main: failed to reconnect to printer")
 		}
@@ -126,7 +162,11 @@ func (app *app) cmdInstallCertAndReset(_ context.Context, args []string) error {
 		// do delete of old cert
 		app.stdLogger.Printf("main: deleting old cert (id: %s) ...", oldCertId)
 		err = print.DeleteCert(oldCertId)
-		if err != nil {
+		var boundErr *printer.CertBoundError
+		if errors.As(err, &boundErr) {
+			app.stdLogger.Printf("WARNING: old cert (id: %s) is still bound to %v, not deleting it", oldCertId, boundErr.Services)
+			return nil
+		} else if err != nil {
 			return fmt.Errorf("main: failed to delete cert (id: %s) (%w)", oldCertId, err)
 		}
 
@@ -28,7 +28,10 @@ type config struct {
 	hostname *string
 	password *string
 	keyCertPemCfg
-	http *bool
+	http          *bool
+	correlationID *string
+	maxValidity   *int
+	dot1x         *bool
 }
 
 // getConfig returns the app's configuration from either command line args,
@@ -40,13 +43,16 @@ func (app *app) getConfig() error {
 	// brother-cert -- root command
 	rootFlags := ff.NewFlagSet("brother-cert")
 
-	cfg.hostname = rootFlags.StringLong("hostname", "", "the hostname of the remote printer")
+	cfg.hostname = rootFlags.StringLong("hostname", "", "the hostname of the remote printer (.local and Bonjour names are resolved via mdns)")
 	cfg.password = rootFlags.StringLong("password", "", "the password to login to the remote printer")
-	cfg.keyPemFilePath = rootFlags.StringLong("keyfile", "", "path and filename of the rsa-2048 key in pem format")
+	cfg.keyPemFilePath = rootFlags.StringLong("keyfile", "", "path and filename of the key (rsa, or ecdsa P-256 or P-384) in pem format")
 	cfg.certPemFilePath = rootFlags.StringLong("certfile", "", "path and filename of the certificate in pem format")
-	cfg.keyPem = rootFlags.StringLong("keypem", "", "string of the rsa-2048 key in pem format")
+	cfg.keyPem = rootFlags.StringLong("keypem", "", "string of the key (rsa, or ecdsa P-256 or P-384) in pem format")
 	cfg.certPem = rootFlags.StringLong("certpem", "", "string of the certificate in pem format")
 	cfg.http = rootFlags.BoolLong("http", "if this flag is set the connection to the printer will use http instead of https (INSECURE)")
+	cfg.dot1x = rootFlags.BoolLong("dot1x", "if this flag is set the new cert is also set as the wired 802.1X client cert")
+	cfg.maxValidity = rootFlags.IntLong("max-validity-days", 0, "if set, refuse to upload a cert valid for longer than this many days (for printers that silently reject long-lived certs)")
+	cfg.correlationID = rootFlags.StringLong("correlation-id", "", "an id to prefix log output with and send to the printer in the X-Correlation-ID header")
 
 	rootCmd := &ff.Command{
 		Name:      "brother-cert",
@@ -0,0 +1,47 @@
+package printer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// WithBasePath sets the path prefix the printer's web UI is under, e.g.
+// `/printer-3` for a printer behind a reverse proxy at
+// `https://proxy/printer-3/`. By default, the path of the base url (or
+// hostname) is used, so this is only needed if it has none.
+func WithBasePath(basePath string) Option {
+	return func(p *printer) {
+		p.basePath = basePath
+	}
+}
+
+// normalizeBasePath returns basePath with a leading slash and no trailing
+// slash, or "" if it is the root
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+
+	return "/" + basePath
+}
+
+// urlPath returns the path of the specified web UI page (e.g. urlCertImport)
+// under the base path
+func (p *printer) urlPath(path string) string {
+	return p.basePath + "/" + strings.TrimPrefix(path, "/")
+}
+
+// underBasePath returns u with the base path prefixed to its path, unless it
+// already has it (e.g. a redirect that a proxy rewrote)
+func (p *printer) underBasePath(u *url.URL) *url.URL {
+	if p.basePath == "" || u.Path == p.basePath || strings.HasPrefix(u.Path, p.basePath+"/") {
+		return u
+	}
+
+	prefixed := *u
+	prefixed.Path = p.urlPath(u.Path)
+	prefixed.RawPath = ""
+
+	return &prefixed
+}
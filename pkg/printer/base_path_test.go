@@ -0,0 +1,112 @@
+package printer
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestURLPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseUrl string
+		opts    []Option
+		want    string
+	}{
+		{"no base path", "https://10.0.0.5", nil, urlCertImport},
+		{"root base url", "https://10.0.0.5/", nil, urlCertImport},
+		{"base url path", "https://proxy/printer-3", nil, "/printer-3" + urlCertImport},
+		{"base url path with trailing slash", "https://proxy/printer-3/", nil, "/printer-3" + urlCertImport},
+		{"nested base url path", "https://proxy/printers/3/", nil, "/printers/3" + urlCertImport},
+		{"WithBasePath", "https://proxy", []Option{WithBasePath("/printer-3")}, "/printer-3" + urlCertImport},
+		{"WithBasePath without leading slash", "https://proxy", []Option{WithBasePath("printer-3")}, "/printer-3" + urlCertImport},
+		{"WithBasePath with trailing slash", "https://proxy", []Option{WithBasePath("/printer-3/")}, "/printer-3" + urlCertImport},
+		{"WithBasePath with extra slashes", "https://proxy", []Option{WithBasePath("//printer-3//")}, "/printer-3" + urlCertImport},
+		{"WithBasePath root", "https://proxy", []Option{WithBasePath("/")}, urlCertImport},
+		{"WithBasePath overrides base url path", "https://proxy/other", []Option{WithBasePath("printer-3")}, "/printer-3" + urlCertImport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.baseUrl, tt.opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if got := p.urlPath(urlCertImport); got != tt.want {
+				t.Errorf("urlPath() = %q, want %q", got, tt.want)
+			}
+			// page paths without a leading slash too
+			if got := p.urlPath(urlCertImport[1:]); got != tt.want {
+				t.Errorf("urlPath() without leading slash = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnderBasePath(t *testing.T) {
+	p, err := New("https://proxy/printer-3/")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{urlCertList, "/printer-3" + urlCertList},
+		// already under the base path (e.g. a rewritten redirect)
+		{"/printer-3" + urlCertList, "/printer-3" + urlCertList},
+		{"/printer-3", "/printer-3"},
+		// a different path that merely starts with the base path
+		{"/printer-30" + urlCertList, "/printer-3/printer-30" + urlCertList},
+	}
+
+	for _, tt := range tests {
+		got := p.underBasePath(&url.URL{Path: tt.path})
+		if got.Path != tt.want {
+			t.Errorf("underBasePath(%q) = %q, want %q", tt.path, got.Path, tt.want)
+		}
+	}
+}
+
+func TestUploadNewCertBasePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		baseUrl  string
+		opts     []Option
+	}{
+		{"none", "", "", nil},
+		{"base url path", "/printer-3", "/printer-3/", nil},
+		{"WithBasePath", "/printer-3", "", []Option{WithBasePath("printer-3/")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockPrinter(t, "1")
+			m.basePath = tt.basePath
+
+			opts := append([]Option{WithPollInterval(10 * time.Millisecond)}, tt.opts...)
+			p, err := New(m.URL+tt.baseUrl, opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			keyPem, certPem := testECKeyCert(t, "printer.example.com")
+			id, err := p.UploadNewCert(keyPem, certPem)
+			if err != nil {
+				t.Fatalf("UploadNewCert() error = %v", err)
+			}
+			if id != "100" {
+				t.Errorf("UploadNewCert() = %q, want %q", id, "100")
+			}
+
+			want := "POST " + m.Listener.Addr().String() + " " + tt.basePath + urlCertImport
+			if !slices.Contains(m.requestLog(), want) {
+				t.Errorf("no request %q in %q", want, m.requestLog())
+			}
+		})
+	}
+}
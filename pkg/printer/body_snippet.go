@@ -0,0 +1,51 @@
+package printer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// maxBodySnippetLen is the number of bytes of a page included in errors
+const maxBodySnippetLen = 200
+
+var (
+	// e.g. `<title>Brother MFC-L2710DW series</title>`
+	regexTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	// e.g. `value="JRL[...snip...]bQ=="` (values of inputs, meta tags, and
+	// script variables are redacted as they may be tokens)
+	regexSnippetSecret = regexp.MustCompile(`(?i)\b(value|content|token\w*)(\s*[=:]\s*)(?:"[^"]*"?|'[^']*'?|[^\s>"']+)`)
+)
+
+// bodySnippet returns a short description of the html response input for
+// errors about pages that couldn't be parsed: its title and the start of the
+// page, with control characters stripped and attribute values redacted
+func bodySnippet(bodyBytes []byte) string {
+	title := ""
+	caps := regexTitle.FindSubmatch(bodyBytes)
+	if len(caps) == 2 {
+		title = htmlToText(caps[1])
+	}
+
+	snippet := bodyBytes
+	truncated := len(snippet) > maxBodySnippetLen
+	if truncated {
+		snippet = snippet[:maxBodySnippetLen]
+	}
+
+	text := strings.ToValidUTF8(string(snippet), "")
+	text = regexSnippetSecret.ReplaceAllString(text, `$1$2"[redacted]"`)
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+	if truncated {
+		text += "..."
+	}
+
+	return fmt.Sprintf("page title: %q, page start: %q", title, text)
+}
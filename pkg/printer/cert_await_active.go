@@ -0,0 +1,115 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// awaitActiveCertInterval is how often the printer is checked while waiting
+// for a cert to become active
+const awaitActiveCertInterval = 5 * time.Second
+
+// verifyActiveCertBaseDelay and verifyActiveCertMaxDelay bound the backoff
+// between checks of VerifyActiveCert
+const (
+	verifyActiveCertBaseDelay = 2 * time.Second
+	verifyActiveCertMaxDelay  = 30 * time.Second
+)
+
+// AwaitActiveCert waits for the printer (e.g. after the reboot triggered by
+// SetActiveCert) to serve the certificate with the specified SHA-256
+// fingerprint (hex), for up to timeout. It is VerifyActiveCert with a
+// timeout.
+//
+// Deprecated: use VerifyActiveCert with a context that has a deadline.
+func (p *printer) AwaitActiveCert(fingerprint string, timeout time.Duration) error {
+	expected, err := parseFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return p.VerifyActiveCert(ctx, expected)
+}
+
+// VerifyActiveCert waits until the printer serves (over https) the cert with
+// the specified SHA-256 fingerprint, e.g. after SetActiveCert reboots it. The
+// printer is checked via a TLS handshake, waiting longer between each check
+// (up to 30 seconds) while it finishes rebooting. nil is returned only once
+// the expected cert is served; if ctx is done first, an error wrapping ctx's
+// error (and describing what the printer last served) is returned.
+func (p *printer) VerifyActiveCert(ctx context.Context, expectedFingerprint []byte) error {
+	if len(expectedFingerprint) != sha256.Size {
+		return fmt.Errorf("printer: invalid sha-256 fingerprint '%s'", hex.EncodeToString(expectedFingerprint))
+	}
+
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+
+	lastResult, err := p.awaitServedCert(ctx, expectedFingerprint, func(attempt int) time.Duration {
+		return min(verifyActiveCertBaseDelay<<min(attempt, 8), verifyActiveCertMaxDelay)
+	})
+	if err != nil {
+		return fmt.Errorf("printer: cert %s not served (%s) (%w)", hex.EncodeToString(expectedFingerprint), lastResult, err)
+	}
+
+	return nil
+}
+
+// awaitServedCert checks the cert the printer serves until its fingerprint
+// is expected, waiting wait(attempt) between checks. If ctx is done first,
+// ctx's error is returned along with a description of the last check.
+func (p *printer) awaitServedCert(ctx context.Context, expected []byte, wait func(attempt int) time.Duration) (lastResult string, err error) {
+	return p.awaitServed(ctx, func(leafCert *x509.Certificate) (bool, string) {
+		served := certFingerprint(leafCert)
+		return bytes.Equal(served, expected), fmt.Sprintf("printer is serving cert with fingerprint %s", hex.EncodeToString(served))
+	}, wait)
+}
+
+// awaitServedSerial checks the cert the printer serves until its serial is
+// expected (compared as numbers, so leading zero bytes don't matter), waiting
+// wait(attempt) between checks. If ctx is done first, ctx's error is returned
+// along with a description of the last check.
+func (p *printer) awaitServedSerial(ctx context.Context, expected []byte, wait func(attempt int) time.Duration) (lastResult string, err error) {
+	expectedSerial := new(big.Int).SetBytes(expected)
+
+	return p.awaitServed(ctx, func(leafCert *x509.Certificate) (bool, string) {
+		return leafCert.SerialNumber.Cmp(expectedSerial) == 0, fmt.Sprintf("printer is serving cert with serial %x", leafCert.SerialNumber)
+	}, wait)
+}
+
+// awaitServed checks the cert the printer serves until match returns true
+// for it, waiting wait(attempt) between checks. match also describes the
+// served cert, to explain a timeout.
+func (p *printer) awaitServed(ctx context.Context, match func(leafCert *x509.Certificate) (bool, string), wait func(attempt int) time.Duration) (lastResult string, err error) {
+	// track last result to explain a timeout
+	lastResult = "printer never responded"
+
+	for attempt := 0; ; attempt++ {
+		leafCert, err := p.getCurrentLeafCert(ctx)
+		if err != nil {
+			// printer likely still rebooting
+			lastResult = err.Error()
+		} else {
+			var matched bool
+			matched, lastResult = match(leafCert)
+			if matched {
+				return "", nil
+			}
+		}
+
+		// wait and try again
+		err = sleepContext(ctx, wait(attempt))
+		if err != nil {
+			return lastResult, err
+		}
+	}
+}
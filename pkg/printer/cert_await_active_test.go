@@ -0,0 +1,27 @@
+package printer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAwaitServedSerialLeadingZero(t *testing.T) {
+	m := newMockPrinterTLS(t, "1")
+
+	p, err := New(m.URL, WithHTTPClient(m.Client()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// the view page may show the serial with a leading zero byte
+	serial := append([]byte{0x00}, m.Certificate().SerialNumber.Bytes()...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lastResult, err := p.awaitServedSerial(ctx, serial, func(int) time.Duration { return 10 * time.Millisecond })
+	if err != nil {
+		t.Fatalf("awaitServedSerial() error = %v (%s)", err, lastResult)
+	}
+}
@@ -0,0 +1,123 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCertBound is returned when deleting a cert that services still use
+var ErrCertBound = errors.New("printer: cert is still bound to services")
+
+// CertBoundError is returned when deleting a cert that services still use. It
+// matches ErrCertBound with errors.Is.
+type CertBoundError struct {
+	// Services are the services that reference the cert
+	Services []Service
+}
+
+// Error implements error
+func (e *CertBoundError) Error() string {
+	services := []string{}
+	for _, service := range e.Services {
+		services = append(services, string(service))
+	}
+
+	return fmt.Sprintf("%s (%s)", ErrCertBound, strings.Join(services, ", "))
+}
+
+// Unwrap returns ErrCertBound
+func (e *CertBoundError) Unwrap() error {
+	return ErrCertBound
+}
+
+// CertBindings are the services that use a cert
+type CertBindings struct {
+	// Services are the services that reference the cert, in the order they
+	// were checked (web ui and ipp first)
+	Services []Service
+}
+
+// IsBound returns true if any service uses the cert
+func (b CertBindings) IsBound() bool {
+	return len(b.Services) > 0
+}
+
+// GetCertBindings scans the http settings page and the other service pages
+// (802.1X, FTP, SMTP, LDAP) and returns the services that use the cert with
+// the specified ID, e.g. to check what would break before deleting it (see
+// also DeleteCertOptions.Force). Pages the model doesn't have are skipped.
+func (p *printer) GetCertBindings(id string) (CertBindings, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	services, err := p.certBindings(ctx, id)
+	if err != nil {
+		return CertBindings{}, err
+	}
+
+	return CertBindings{Services: services}, nil
+}
+
+// bindingCheckServices are the services with their own cert page, in the
+// order they are checked
+var bindingCheckServices = []Service{ServiceDot1x, ServiceFTP, ServiceSMTP, ServiceLDAP}
+
+// certBindings scans the http settings page and the other service pages and
+// returns the services that reference the cert with the specified ID. pages
+// the model doesn't have are skipped.
+func (p *printer) certBindings(ctx context.Context, id string) ([]Service, error) {
+	bound := []Service{}
+
+	// http settings page (web ui, ipp)
+	bodyBytes, err := p.getHttpSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := parseHttpSettingsFormFields(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if parseFormValues(bodyBytes).Get(fields.certSelectField) == id {
+		for _, service := range []Service{ServiceWebUI, ServiceIPP} {
+			for _, checkbox := range fields.httpsFields {
+				if checkbox.checked && strings.Contains(strings.ToLower(checkbox.label), strings.ToLower(serviceHttpsLabels[service])) {
+					bound = append(bound, service)
+					break
+				}
+			}
+		}
+
+		// the cert is the https cert even if the checkboxes aren't identifiable
+		if len(bound) == 0 {
+			bound = append(bound, ServiceWebUI)
+		}
+	}
+
+	// services with their own page
+	for _, service := range bindingCheckServices {
+		bodyBytes, err := p.getOptionalPage(ctx, serviceCertPages[service])
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		selectField := parseCertSelectName(bodyBytes)
+		if selectField == "" {
+			continue
+		}
+
+		if parseFormValues(bodyBytes).Get(selectField) == id {
+			bound = append(bound, service)
+		}
+	}
+
+	return bound, nil
+}
@@ -0,0 +1,201 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// the CA certificate store is separate from the printer's own certificates
+// (not all models have it)
+const (
+	urlCACertList   = "/net/security/certificate/ca_cert.html"
+	urlCACertImport = "/net/security/certificate/ca_import.html"
+)
+
+// getCACertIDs returns the IDs of the certs in the CA certificate store
+func (p *printer) getCACertIDs(ctx context.Context) ([]string, error) {
+	bodyBytes, err := p.getOptionalPage(ctx, urlCACertList)
+	if err != nil {
+		return nil, err
+	}
+
+	// e.g. `<a href="ca_view.html?idx=3">View</a>`
+	ids := []string{}
+	for _, id := range parseCertIDs(bodyBytes) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// ImportCACert installs the CA (root or intermediate) certificate in
+// certPem into the printer's trusted CA store (e.g. so the printer trusts an
+// LDAP or SMTP server), which is separate from the printer's own certs. It
+// returns the id value of the new CA cert. If the model doesn't have a CA
+// store, ErrUnsupported is returned.
+func (p *printer) ImportCACert(certPem []byte) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// sanity check
+	block, _ := pem.Decode(certPem)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New("printer: ca import: failed to decode cert pem")
+	}
+	_, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("printer: ca import: failed to parse cert (%w)", err)
+	}
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	id := ""
+	err = p.withSessionRetry(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = p.importCACert(p.dryRunContext(ctx, "import ca cert", ""), pem.EncodeToMemory(block))
+		return err
+	})
+
+	return id, err
+}
+
+// importCACert performs ImportCACert using ctx
+func (p *printer) importCACert(ctx context.Context, certPem []byte) (string, error) {
+	// GET current CA cert IDs
+	origCertIDs, err := p.getCACertIDs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// GET import page
+	bodyBytes, err := p.getOptionalPage(ctx, urlCACertImport)
+	if err != nil {
+		return "", err
+	}
+
+	p.logForm(ctx, urlCACertImport, bodyBytes)
+
+	// find CSRFToken
+	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	fileField, err := parseFileFieldName(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	// make multipart/form-data submission of the page's fields and the cert
+	var formDataBuffer bytes.Buffer
+	formWriter := multipart.NewWriter(&formDataBuffer)
+
+	values := parseFormValues(bodyBytes)
+	values.Set(csrfToken.name, csrfToken.value)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		for _, value := range values[name] {
+			err = formWriter.WriteField(name, value)
+			if err != nil {
+				return "", fmt.Errorf("printer: ca import: failed to write form (%w)", err)
+			}
+		}
+	}
+
+	fileWriter, err := formWriter.CreateFormFile(fileField, "ca.pem")
+	if err != nil {
+		return "", fmt.Errorf("printer: ca import: failed to write form (%w)", err)
+	}
+	_, err = fileWriter.Write(certPem)
+	if err != nil {
+		return "", fmt.Errorf("printer: ca import: failed to write form (%w)", err)
+	}
+
+	err = formWriter.Close()
+	if err != nil {
+		return "", fmt.Errorf("printer: ca import: failed to close form (%w)", err)
+	}
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return "", err
+	}
+	u.Path = p.urlPath(urlCACertImport)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		err = plan.planMultipartForm(u, formWriter.FormDataContentType(), formDataBuffer.Bytes())
+		if err != nil {
+			return "", err
+		}
+		return "", plan
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &formDataBuffer)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", formWriter.FormDataContentType())
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK && !isRedirect(resp.StatusCode) {
+		return "", newHTTPStatusError("post of ca certificate", resp)
+	}
+
+	// rejected?
+	if regexDuplicateCert.MatchString(htmlToText(bodyBytes)) {
+		return "", ErrDuplicateCert
+	}
+	err = checkBodyForImportFailed(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	// poll the CA list until the new cert appears
+	newCertIDs, err := p.awaitNewIDs(ctx, origCertIDs, p.getCACertIDs)
+	if err != nil {
+		return "", err
+	}
+
+	newId, err := diffNewCertID(origCertIDs, newCertIDs)
+	if err != nil {
+		return "", err
+	}
+	if newId == "" {
+		return "", errors.New("printer: ca import: no new ca cert appeared")
+	}
+
+	return newId, nil
+}
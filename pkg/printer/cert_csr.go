@@ -0,0 +1,440 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errCSRCommonNameRequired = errors.New("printer: csr: common name is required")
+
+var (
+	// e.g. `-----BEGIN CERTIFICATE REQUEST-----` ... (e.g. in a textarea)
+	regexCSRPem = regexp.MustCompile(`(?s)-----BEGIN (?:NEW )?CERTIFICATE REQUEST-----.*?-----END (?:NEW )?CERTIFICATE REQUEST-----`)
+	// e.g. `<a href="csr.csr">Download</a>`
+	regexCSRLink = regexp.MustCompile(`(?i)\bcsr\b|download`)
+	// text between two tags, e.g. `>Common Name<`
+	regexTextSegment = regexp.MustCompile(`>([^<]*)<`)
+)
+
+// csrField is a field of the Create CSR form
+type csrField int
+
+const (
+	csrFieldUnknown csrField = iota
+	csrFieldCommonName
+	csrFieldOrganization
+	csrFieldOrganizationalUnit
+	csrFieldLocality
+	csrFieldState
+	csrFieldCountry
+	csrFieldSAN
+)
+
+// csrFieldLabels identify the fields of the Create CSR form by their labels.
+// order matters (e.g. `Organizational Unit` also matches organization).
+var csrFieldLabels = []struct {
+	field csrField
+	regex *regexp.Regexp
+}{
+	{csrFieldSAN, regexp.MustCompile(`(?i)alternative|\bSAN\b`)},
+	{csrFieldCommonName, regexp.MustCompile(`(?i)common\s*name|\bCN\b`)},
+	{csrFieldOrganizationalUnit, regexp.MustCompile(`(?i)organi[sz]ation(?:al)?\s*unit|\bOU\b`)},
+	{csrFieldOrganization, regexp.MustCompile(`(?i)organi[sz]ation`)},
+	{csrFieldLocality, regexp.MustCompile(`(?i)locality|city`)},
+	{csrFieldState, regexp.MustCompile(`(?i)state|province`)},
+	{csrFieldCountry, regexp.MustCompile(`(?i)country`)},
+}
+
+// CSRParams are the values of the certificate signing request the printer
+// generates. Empty fields are left as the printer's defaults.
+type CSRParams struct {
+	CommonName         string
+	Organization       string
+	OrganizationalUnit string
+	Locality           string
+	State              string
+	// Country is the 2 letter country code
+	Country string
+
+	// KeyAlgo is the key type and size (see SupportedKeyAlgorithms). If
+	// zero, the printer's default is used.
+	KeyAlgo KeyAlgo
+
+	// SANs are the subject alternative names (e.g. DNS names). Not all models
+	// support them.
+	SANs []string
+}
+
+// fieldText returns the label of the field with the specified id, which
+// starts at position start of bodyBytes. Unlike precedingLabel, if there is
+// no text right before the field, the nearest text before it (e.g. in the
+// previous table cell) is used.
+func fieldText(bodyBytes []byte, start int, id string, labels map[string]string) string {
+	label := precedingLabel(bodyBytes, start, id, labels)
+	if label != "" {
+		return label
+	}
+
+	before := bodyBytes[max(0, start-512):start]
+	segments := regexTextSegment.FindAllSubmatch(append(bytes.Clone(before), '<'), -1)
+	for i := len(segments) - 1; i >= 0; i-- {
+		text := htmlToText(segments[i][1])
+		if text != "" {
+			return text
+		}
+	}
+
+	return ""
+}
+
+// csrFieldFromLabel returns the field type from its label
+func csrFieldFromLabel(label string) csrField {
+	for _, l := range csrFieldLabels {
+		if l.regex.MatchString(label) {
+			return l.field
+		}
+	}
+
+	return csrFieldUnknown
+}
+
+// parseCSRFieldNames returns the names of the text inputs of the Create CSR
+// form by field type. Some models have more than one SAN input.
+func parseCSRFieldNames(bodyBytes []byte) map[csrField][]string {
+	labels := parseLabels(bodyBytes)
+
+	names := make(map[csrField][]string)
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		switch strings.ToLower(attrs["type"]) {
+		case "", "text":
+		default:
+			continue
+		}
+		if attrs["name"] == "" {
+			continue
+		}
+
+		field := csrFieldFromLabel(fieldText(bodyBytes, input.start, attrs["id"], labels))
+		if field != csrFieldUnknown {
+			names[field] = append(names[field], attrs["name"])
+		}
+	}
+
+	return names
+}
+
+// parseKeyAlgoSelect returns the name of the key type select of the Create
+// CSR form and the value of the option for algo
+func parseKeyAlgoSelect(bodyBytes []byte, algo KeyAlgo) (name string, value string, err error) {
+	for _, sel := range parseSelects(bodyBytes) {
+		isKeySelect := false
+		for _, option := range sel.options {
+			caps := regexKeyAlgo.FindStringSubmatch(option.text(bodyBytes))
+			if len(caps) != 3 {
+				continue
+			}
+			isKeySelect = true
+
+			optAlgo := strings.ToUpper(caps[1])
+			if optAlgo == "EC" {
+				optAlgo = "ECDSA"
+			}
+			if optAlgo != strings.ToUpper(algo.Algorithm) || caps[2] != strconv.Itoa(algo.Bits) {
+				continue
+			}
+
+			return sel.attrs["name"], option.attrs["value"], nil
+		}
+
+		if isKeySelect {
+			return "", "", fmt.Errorf("%w (key type %s not offered)", ErrUnsupported, algo)
+		}
+	}
+
+	return "", "", fmt.Errorf("%w (key type select not found)", ErrUnsupported)
+}
+
+// parseCSRPem returns the CSR shown in the html (or pem) response input
+func parseCSRPem(bodyBytes []byte) ([]byte, bool) {
+	csrPem := regexCSRPem.Find([]byte(html.UnescapeString(string(bodyBytes))))
+	if csrPem == nil {
+		return nil, false
+	}
+
+	// sanity check
+	block, _ := pem.Decode(csrPem)
+	if block == nil {
+		return nil, false
+	}
+	_, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, false
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: block.Bytes}), true
+}
+
+// GenerateCSR makes the printer generate a private key and a certificate
+// signing request for it, using its Create CSR page. The key never leaves the
+// printer; the signed cert can then be installed with UploadNewCert. The CSR
+// is returned in pem form. If the model can't generate CSRs, ErrUnsupported
+// is returned.
+func (p *printer) GenerateCSR(params CSRParams) (csrPem []byte, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if params.CommonName == "" {
+		return nil, errCSRCommonNameRequired
+	}
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	err = p.withSessionRetry(ctx, func(ctx context.Context) error {
+		csrPem, err = p.generateCSR(p.dryRunContext(ctx, "generate csr", ""), params)
+		return err
+	})
+
+	return csrPem, err
+}
+
+// generateCSR performs GenerateCSR using ctx
+func (p *printer) generateCSR(ctx context.Context, params CSRParams) ([]byte, error) {
+	// GET create csr page
+	bodyBytes, err := p.getOptionalPage(ctx, urlCertCreateCSR)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logForm(ctx, urlCertCreateCSR, bodyBytes)
+
+	// form values are the page's defaults with the params filled in
+	data := parseFormValues(bodyBytes)
+	if !hasCSRFToken(data) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return nil, err
+		}
+		data.Set(csrfToken.name, csrfToken.value)
+	}
+
+	fieldNames := parseCSRFieldNames(bodyBytes)
+	if len(fieldNames[csrFieldCommonName]) == 0 {
+		return nil, errors.New("printer: csr: common name field not found on page")
+	}
+
+	values := map[csrField]string{
+		csrFieldCommonName:         params.CommonName,
+		csrFieldOrganization:       params.Organization,
+		csrFieldOrganizationalUnit: params.OrganizationalUnit,
+		csrFieldLocality:           params.Locality,
+		csrFieldState:              params.State,
+		csrFieldCountry:            params.Country,
+	}
+	for field, value := range values {
+		if value == "" {
+			continue
+		}
+
+		names := fieldNames[field]
+		if len(names) == 0 {
+			return nil, fmt.Errorf("printer: csr: field for %q not found on page", value)
+		}
+		data.Set(names[0], value)
+	}
+
+	// SANs: one per input, or all in one
+	if len(params.SANs) > 0 {
+		names := fieldNames[csrFieldSAN]
+		switch {
+		case len(names) == 0:
+			return nil, fmt.Errorf("%w (model doesn't support SANs in csr)", ErrUnsupported)
+		case len(names) == 1:
+			data.Set(names[0], strings.Join(params.SANs, ","))
+		case len(names) < len(params.SANs):
+			return nil, fmt.Errorf("printer: csr: too many SANs (model supports %d)", len(names))
+		default:
+			for i, san := range params.SANs {
+				data.Set(names[i], san)
+			}
+		}
+	}
+
+	if params.KeyAlgo != (KeyAlgo{}) {
+		name, value, err := parseKeyAlgoSelect(bodyBytes, params.KeyAlgo)
+		if err != nil {
+			return nil, err
+		}
+		data.Set(name, value)
+	}
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = p.urlPath(urlCertCreateCSR)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		plan.planForm(u, data)
+		return nil, plan
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK && !isRedirect(resp.StatusCode) {
+		return nil, newHTTPStatusError("post of create csr form", resp)
+	}
+
+	return p.awaitCSR(ctx, bodyBytes)
+}
+
+// awaitCSR returns the CSR once the printer has generated it (generating the
+// key can take a while). it is shown on the result page, or downloaded from a
+// link on the result page or the cert list.
+func (p *printer) awaitCSR(ctx context.Context, resultBody []byte) ([]byte, error) {
+	deadline := time.Now().Add(p.pollTimeout)
+
+	bodyBytes := resultBody
+	for {
+		csrPem, err := p.findCSR(ctx, bodyBytes)
+		if err == nil {
+			return csrPem, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.New("printer: csr: timed out waiting for printer to generate csr")
+		}
+
+		err = sleepContext(ctx, p.pollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("printer: csr: %w", err)
+		}
+
+		// session may have expired while waiting
+		err = p.refreshSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		bodyBytes, err = p.getCertListPage(ctx, "")
+		if err != nil {
+			// device may still be busy
+			bodyBytes = nil
+		}
+	}
+}
+
+// findCSR returns the CSR shown on the page, or downloaded from a CSR link on
+// it
+func (p *printer) findCSR(ctx context.Context, bodyBytes []byte) ([]byte, error) {
+	csrPem, ok := parseCSRPem(bodyBytes)
+	if ok {
+		return csrPem, nil
+	}
+
+	for _, anchor := range parseElements(bodyBytes, "a") {
+		href := anchor.attrs["href"]
+		if href == "" || !regexCSRLink.MatchString(href) {
+			continue
+		}
+
+		downloadBody, err := p.getCSRDownload(ctx, href)
+		if err != nil {
+			continue
+		}
+
+		csrPem, ok := parseCSRPem(downloadBody)
+		if ok {
+			return csrPem, nil
+		}
+
+		// may be der
+		_, err = x509.ParseCertificateRequest(downloadBody)
+		if err == nil {
+			return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: downloadBody}), nil
+		}
+	}
+
+	return nil, errors.New("printer: csr: csr not found on page")
+}
+
+// getCSRDownload fetches the CSR download link href (which is relative to
+// the Create CSR page)
+func (p *printer) getCSRDownload(ctx context.Context, href string) ([]byte, error) {
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = p.urlPath(urlCertCreateCSR)
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.underBasePath(u.ResolveReference(ref)).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPStatusError("get of csr download", resp)
+	}
+
+	return bodyBytes, nil
+}
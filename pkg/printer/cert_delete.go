@@ -1,53 +1,188 @@
 package printer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 )
 
 const urlCertDelete = "/net/security/certificate/delete.html"
 
+// deleteButtonFields and deleteConfirmButtonFields are the submit button
+// fields of the first and second (confirmation) delete forms
+var (
+	deleteButtonFields        = []string{"B8ea", "B8fc"}
+	deleteConfirmButtonFields = []string{"B8ea", "B8eb"}
+)
+
 var errCertDeleteInvalidID = errors.New("printer: cant delete cert (invalid id)")
 
+// ErrCertInUse is returned when deleting the printer's active (https) cert,
+// which would break the web UI until the printer falls back to a default
+// cert. Use DeleteCertOptions.AllowActive to delete it anyway.
+var ErrCertInUse = errors.New("printer: cant delete cert (it is the active cert)")
+
+// DeleteCertOptions modifies the behavior of DeleteCertWithOptions
+type DeleteCertOptions struct {
+	// Force skips the check that the ID is in the printer's certificate list
+	// and attempts the delete anyway. Some printers do not list certs that
+	// lack a Common Name, even though they can still be deleted. It also
+	// deletes a cert that services are still bound to (see BindingWarning).
+	Force bool
+
+	// AllowActive allows deleting the active cert (otherwise ErrCertInUse is
+	// returned). If the active cert can't be determined, the delete fails
+	// unless AllowActive is set.
+	AllowActive bool
+
+	// VerifyFreedSlot additionally confirms the number of used slots in the
+	// cert store decreased, so the cert was actually removed and not just
+	// hidden from the list. If the model doesn't show store usage,
+	// ErrUnsupported is returned before anything is deleted.
+	VerifyFreedSlot bool
+
+	// BindingWarning is called with the services still bound to the cert when
+	// Force deletes it anyway. Before every delete the service pages (https,
+	// 802.1X, FTP, SMTP, LDAP) are scanned for references to the cert and, if
+	// it is bound, a *CertBoundError is returned unless Force is set. The
+	// https bindings of the active cert don't refuse the delete when
+	// AllowActive is set.
+	BindingWarning func(services []Service)
+}
+
 // DeleteCert deletes the certificate with the specified ID from the
-// printer
+// printer. The active cert isn't deleted (ErrCertInUse is returned) and
+// neither is a cert that services are still bound to (a *CertBoundError is
+// returned).
 func (p *printer) DeleteCert(id string) error {
-	// verify ID actually exists and isn't 0 ('Preset') which isn't valid
+	return p.DeleteCertWithOptions(id, DeleteCertOptions{})
+}
+
+// DeleteCertContext performs DeleteCert, stopping when ctx is done
+// (including during the wait for the printer to remove the cert)
+func (p *printer) DeleteCertContext(ctx context.Context, id string) error {
+	return p.deleteCertContext(ctx, id, DeleteCertOptions{})
+}
+
+// DeleteCertWithOptions deletes the certificate with the specified ID from
+// the printer, using the specified options
+func (p *printer) DeleteCertWithOptions(id string, opts DeleteCertOptions) error {
+	return p.deleteCertContext(context.Background(), id, opts)
+}
+
+// deleteCertContext performs deleteCert bounded by ctx and the operation
+// budget, and reports cancellation as ctx's error
+func (p *printer) deleteCertContext(ctx context.Context, id string, opts DeleteCertOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+
+	err := p.withSessionRetry(ctx, func(ctx context.Context) error {
+		return p.withCSRFRetry(ctx, func(ctx context.Context) error {
+			return p.deleteCert(p.dryRunContext(ctx, "delete cert", id), id, opts)
+		})
+	})
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("printer: delete: %w", ctx.Err())
+	}
+
+	return err
+}
+
+// deleteCert performs DeleteCertWithOptions using ctx
+func (p *printer) deleteCert(ctx context.Context, id string, opts DeleteCertOptions) error {
+	// verify ID isn't 0 ('Preset') which isn't valid (even if forced)
 	if len(id) <= 0 || id == "0" {
 		return errCertDeleteInvalidID
 	}
 
-	existingIDs, err := p.getCertIDs()
+	// verify ID actually exists (unless forced)
+	if !opts.Force {
+		existingIDs, err := p.getCertIDs(ctx)
+		if err != nil {
+			return err
+		}
+
+		validID := false
+		for _, existingID := range existingIDs {
+			if existingID == id {
+				validID = true
+				break
+			}
+		}
+		if !validID {
+			return errCertDeleteInvalidID
+		}
+	}
+
+	// active cert? (if it can't be determined, don't delete)
+	if !opts.AllowActive {
+		activeID, err := p.getActiveCert(ctx)
+		if err != nil {
+			p.logger.DebugContext(ctx, "printer: failed to get active cert before delete, trying current cert id", "error", err)
+
+			activeID, _, err = p.getCurrentCertID(ctx)
+			if err != nil {
+				return fmt.Errorf("printer: cant delete cert (failed to determine active cert) (%w)", err)
+			}
+		}
+
+		if activeID == id {
+			return fmt.Errorf("%w (id %s)", ErrCertInUse, id)
+		}
+	}
+
+	// cert still in use?
+	services, err := p.certBindings(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	validID := false
-	for _, existingID := range existingIDs {
-		if existingID == id {
-			validID = true
-			break
+	if len(services) > 0 {
+		refusing := services
+		if opts.AllowActive {
+			refusing = slices.DeleteFunc(slices.Clone(services), func(service Service) bool {
+				return service == ServiceWebUI || service == ServiceIPP
+			})
+		}
+
+		if len(refusing) > 0 && !opts.Force {
+			return &CertBoundError{Services: services}
+		}
+
+		if opts.BindingWarning != nil {
+			opts.BindingWarning(services)
 		}
 	}
-	if !validID {
-		return errCertDeleteInvalidID
+
+	// store usage before delete
+	var origUsage StoreUsage
+	if opts.VerifyFreedSlot {
+		origUsage, err = p.getStoreUsage(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
 	// first get the delete page to get CSRFToken
+	p.progress(ProgressFetchingDeletePage, 10)
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return err
 	}
-	u.Path = urlCertDelete
+	u.Path = p.urlPath(urlCertDelete)
 
 	// make and do request
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -69,11 +204,19 @@ func (p *printer) DeleteCert(id string) error {
 		return err
 	}
 
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return err
+	}
+
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("printer: get of delete page failed (status code %d)", resp.StatusCode)
+		return newHTTPStatusError("get of delete page", resp)
 	}
 
+	p.logForm(ctx, urlCertDelete, bodyBytes)
+
 	// find CSRFToken
 	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
 	if err != nil {
@@ -84,9 +227,10 @@ func (p *printer) DeleteCert(id string) error {
 	// form values
 	data := url.Values{}
 	data.Set("pageid", "383")
-	data.Set("CSRFToken", csrfToken)
-	data.Set("B8ea", "")
-	data.Set("B8fc", "")
+	data.Set(csrfToken.name, csrfToken.value)
+	for _, field := range deleteButtonFields {
+		data.Set(field, "")
+	}
 	data.Set("hidden_certificate_process_control", "1")
 	data.Set("hidden_certificate_idx", id)
 
@@ -95,10 +239,17 @@ func (p *printer) DeleteCert(id string) error {
 	if err != nil {
 		return err
 	}
-	u.Path = urlCertDelete
+	u.Path = p.urlPath(urlCertDelete)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		plan.planForm(u, data)
+		return plan
+	}
 
 	// make and do request
-	req, err = http.NewRequest(http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	p.progress(ProgressDeleting, 30)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
@@ -117,11 +268,30 @@ func (p *printer) DeleteCert(id string) error {
 		return err
 	}
 
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return err
+	}
+
+	// token used by another session?
+	err = checkBodyForCSRFTokenRejected(bodyBytes)
+	if err != nil {
+		return err
+	}
+
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("printer: get failed (status code %d)", resp.StatusCode)
+		return newHTTPStatusError("post of delete form", resp)
 	}
 
+	// the delete was accepted; from here on a lost session is logged in again
+	// and the delete resumes (starting over would find the cert already gone
+	// and report the delete as failed)
+	ctx = withoutSessionCheck(ctx)
+
+	p.logForm(ctx, urlCertDelete, bodyBytes)
+
 	// find CSRFToken
 	csrfToken, err = parseBodyForCSRFToken(bodyBytes)
 	if err != nil {
@@ -132,9 +302,10 @@ func (p *printer) DeleteCert(id string) error {
 	// form values
 	data = url.Values{}
 	data.Set("pageid", "383")
-	data.Set("CSRFToken", csrfToken)
-	data.Set("B8ea", "")
-	data.Set("B8eb", "")
+	data.Set(csrfToken.name, csrfToken.value)
+	for _, field := range deleteConfirmButtonFields {
+		data.Set(field, "")
+	}
 	data.Set("hidden_certificate_process_control", "2")
 	data.Set("hidden_certificate_idx", id)
 
@@ -143,10 +314,10 @@ func (p *printer) DeleteCert(id string) error {
 	if err != nil {
 		return err
 	}
-	u.Path = urlCertDelete
+	u.Path = p.urlPath(urlCertDelete)
 
 	// make and do request
-	req, err = http.NewRequest(http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
@@ -159,30 +330,72 @@ func (p *printer) DeleteCert(id string) error {
 	}
 	defer resp.Body.Close()
 
-	// read and discard entire body
-	_, _ = io.Copy(io.Discard, resp.Body)
+	// read body of response (the list is checked below regardless)
+	bodyBytes, _ = io.ReadAll(resp.Body)
 
-	// normally the webUI would show a waiting screen for ~7 seconds. insert
-	// a delay here to account for any processing the device might do
-	// before next steps
-	time.Sleep(10 * time.Second)
+	// token used by another session?
+	err = checkBodyForCSRFTokenRejected(bodyBytes)
+	if err != nil {
+		return err
+	}
 
-	// check id list and ensure its gone
-	existingIDs, err = p.getCertIDs()
+	// normally the webUI would show a waiting screen for ~7 seconds. poll the
+	// id list until the id is gone, to account for any processing the device
+	// might do (or a cached list) before next steps
+	// NOTE: if forced, the id may never have been listed so this check is
+	// weaker; it still catches the case where the delete was rejected for a
+	// listed cert
+	p.progress(ProgressWaitingForDevice, 60)
+	err = p.awaitCertIDGone(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	idFound := false
-	for _, existingID := range existingIDs {
-		if existingID == id {
-			idFound = true
-			break
+	// verify the slot was freed
+	if opts.VerifyFreedSlot {
+		p.progress(ProgressVerifying, 90)
+		newUsage, err := p.getStoreUsage(ctx)
+		if err != nil {
+			return err
+		}
+
+		if newUsage.Used >= origUsage.Used {
+			return fmt.Errorf("printer: delete: cert store slot not freed (used count was %d, now %d)", origUsage.Used, newUsage.Used)
 		}
-	}
-	if idFound {
-		return errors.New("printer: failed to delete cert (still exists)")
 	}
 
+	p.progress(ProgressDone, 100)
 	return nil
 }
+
+// awaitCertIDGone polls the cert ID list until it no longer contains id.
+// errors getting the list are tolerated (the device may be busy processing
+// the delete) until the poll timeout.
+func (p *printer) awaitCertIDGone(ctx context.Context, id string) error {
+	deadline := time.Now().Add(p.pollTimeout)
+
+	for {
+		err := sleepContext(ctx, p.pollInterval)
+		if err != nil {
+			return fmt.Errorf("printer: delete: %w", err)
+		}
+
+		// session may have expired while waiting
+		err = p.refreshSession(ctx)
+		if err != nil {
+			return err
+		}
+
+		existingIDs, err := p.getCertIDs(ctx)
+		if err == nil && !slices.Contains(existingIDs, id) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return err
+			}
+			return errors.New("printer: failed to delete cert (still exists)")
+		}
+	}
+}
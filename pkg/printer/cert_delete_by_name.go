@@ -0,0 +1,57 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errCertCommonNameNotFound = errors.New("printer: no cert with the specified common name")
+
+// DeleteCertByCommonName deletes the certificate with the specified Common
+// Name (as shown in the printer's certificate list), or for a cert without
+// one, its first DNS SAN. If more than one cert has the name, nothing is
+// deleted and an error listing their IDs is returned.
+func (p *printer) DeleteCertByCommonName(cn string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.withSessionRetry(ctx, func(ctx context.Context) error {
+		id, err := p.certIDByCommonName(ctx, cn)
+		if err != nil {
+			return err
+		}
+
+		return p.deleteCert(p.dryRunContext(ctx, "delete cert", id), id, DeleteCertOptions{})
+	})
+}
+
+// certIDByCommonName returns the ID of the only cert with the specified
+// Common Name
+func (p *printer) certIDByCommonName(ctx context.Context, cn string) (string, error) {
+	infos, err := p.listCerts(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	matchIDs := []string{}
+	for _, info := range infos {
+		// certs without a Common Name go by their display name (first SAN)
+		if strings.EqualFold(info.DisplayName, cn) {
+			matchIDs = append(matchIDs, info.ID)
+		}
+	}
+
+	switch len(matchIDs) {
+	case 0:
+		return "", fmt.Errorf("%w (%s)", errCertCommonNameNotFound, cn)
+	case 1:
+		return matchIDs[0], nil
+	default:
+		return "", fmt.Errorf("printer: more than one cert has common name %s (ids: %s)", cn, strings.Join(matchIDs, ", "))
+	}
+}
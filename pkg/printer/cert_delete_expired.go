@@ -0,0 +1,72 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrExpiredActiveCert is returned by DeleteExpiredCerts (along with the IDs
+// of the certs it did delete) when the active cert has expired, since it is
+// skipped rather than deleted
+var ErrExpiredActiveCert = errors.New("printer: active cert has expired (not deleted)")
+
+// DeleteExpiredCerts deletes every cert in the printer's certificate list
+// whose NotAfter has passed, and returns the IDs of the deleted certs. The
+// Preset (ID 0) and certs whose expiry isn't shown are never deleted. The
+// active cert is skipped, in which case ErrExpiredActiveCert is returned with
+// the IDs. If a delete fails, the IDs deleted so far are returned with the
+// error.
+func (p *printer) DeleteExpiredCerts() ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	deletedIDs := []string{}
+	skippedActiveID := ""
+	err := p.withSessionRetry(ctx, func(ctx context.Context) error {
+		infos, err := p.listCerts(ctx)
+		if err != nil {
+			return err
+		}
+
+		activeID, _, err := p.getCurrentCertID(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, info := range infos {
+			if info.ID == "0" || info.NotAfter.IsZero() || !info.NotAfter.Before(now) {
+				continue
+			}
+
+			// never delete the cert in use
+			if info.ID == activeID {
+				skippedActiveID = info.ID
+				continue
+			}
+
+			err = p.deleteCert(p.dryRunContext(ctx, "delete cert", info.ID), info.ID, DeleteCertOptions{})
+			if err != nil {
+				return err
+			}
+
+			deletedIDs = append(deletedIDs, info.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return deletedIDs, err
+	}
+
+	if skippedActiveID != "" {
+		return deletedIDs, fmt.Errorf("%w (id %s)", ErrExpiredActiveCert, skippedActiveID)
+	}
+
+	return deletedIDs, nil
+}
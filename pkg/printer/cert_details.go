@@ -0,0 +1,149 @@
+package printer
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrCertNotFound is returned when there is no cert with the specified ID
+var ErrCertNotFound = errors.New("printer: cert not found")
+
+// e.g. `<br>` or `<br />` (separating list entries in a detail)
+var regexLineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// CertDetails is the metadata of a certificate, as shown on its view page.
+// Fields the page doesn't show are empty.
+// CertDetails marshal to JSON with RFC3339 dates, omitting fields the page
+// doesn't show.
+type CertDetails struct {
+	ID                 string    `json:"id"`
+	Subject            string    `json:"subject,omitempty"`
+	Issuer             string    `json:"issuer,omitempty"`
+	Serial             string    `json:"serial,omitempty"`
+	NotBefore          time.Time `json:"not_before,omitzero"`
+	NotAfter           time.Time `json:"not_after,omitzero"`
+	KeyUsage           []string  `json:"key_usage,omitempty"`
+	SANs               []string  `json:"sans,omitempty"`
+	SignatureAlgorithm string    `json:"signature_algorithm,omitempty"`
+
+	// Fingerprint is the SHA-256 fingerprint (lowercase hex, without
+	// separators), if the page shows it
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// splitDetailList splits a detail value that lists entries (separated by
+// line breaks or commas), e.g. the SANs
+func splitDetailList(value []byte) []string {
+	text := htmlToText(regexLineBreak.ReplaceAll(value, []byte(",")))
+
+	entries := []string{}
+	for _, entry := range strings.Split(text, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// parseCertDetails parses the details (`<dt>label</dt><dd>value</dd>` pairs)
+// of a cert view page. Dates are in UTC.
+func parseCertDetails(id string, bodyBytes []byte) (*CertDetails, error) {
+	details := &CertDetails{ID: id}
+
+	found := false
+	for _, detail := range regexCertViewDetail.FindAllSubmatch(bodyBytes, -1) {
+		found = true
+
+		label := strings.ToLower(htmlToText(detail[1]))
+		text := htmlToText(detail[2])
+		if text == "" {
+			continue
+		}
+
+		var err error
+		switch {
+		// before "name" and "key" (e.g. `Subject Alternative Name`)
+		case strings.Contains(label, "alternative"), label == "san":
+			details.SANs = splitDetailList(detail[2])
+		case strings.Contains(label, "key usage"):
+			details.KeyUsage = append(details.KeyUsage, splitDetailList(detail[2])...)
+		case strings.Contains(label, "public key"):
+			// e.g. `Subject Public Key Info` (not the subject)
+			continue
+		case strings.Contains(label, "signature"):
+			details.SignatureAlgorithm = text
+		case strings.Contains(label, "issuer"):
+			details.Issuer = text
+		case strings.Contains(label, "subject"), strings.Contains(label, "common name"):
+			// prefer the full subject over the common name
+			if details.Subject == "" || strings.Contains(label, "subject") {
+				details.Subject = text
+			}
+		case strings.Contains(label, "serial"):
+			details.Serial = text
+		case strings.Contains(label, "not before"), strings.Contains(label, "valid from"):
+			details.NotBefore, err = parseCertTime(text)
+		case strings.Contains(label, "not after"), strings.Contains(label, "valid to"), strings.Contains(label, "expir"), strings.Contains(label, "validity"):
+			// either the expiration alone or a range
+			dates := regexDateRange.FindStringSubmatch(text)
+			if len(dates) == 3 {
+				details.NotBefore, err = parseCertTime(dates[1])
+				if err == nil {
+					details.NotAfter, err = parseCertTime(dates[2])
+				}
+			} else {
+				details.NotAfter, err = parseCertTime(text)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("printer: failed to parse cert (id: %s) details (%w)", id, err)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("printer: cert (id: %s) view page has no details", id)
+	}
+
+	fingerprint, ok := parseCertViewFingerprint(bodyBytes)
+	if ok {
+		details.Fingerprint = hex.EncodeToString(fingerprint)
+	}
+
+	return details, nil
+}
+
+// GetCertDetails returns the metadata of the certificate with the specified
+// ID, as shown on its view page (e.g. to check its SANs before activating
+// it). Dates are in UTC. If there is no cert with the ID, ErrCertNotFound is
+// returned.
+func (p *printer) GetCertDetails(id string) (*CertDetails, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	// the view page of a missing cert is not necessarily an error page
+	existingIDs, err := p.getCertIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(existingIDs, id) {
+		return nil, fmt.Errorf("%w (id: %s)", ErrCertNotFound, id)
+	}
+
+	bodyBytes, err := p.getCertViewPage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCertDetails(id, bodyBytes)
+}
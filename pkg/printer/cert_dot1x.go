@@ -0,0 +1,32 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetDot1xCert sets the client certificate used for wired 802.1X network
+// authentication to the specified ID. This is separate from the https cert,
+// so it must also be updated when rotating a cert that is used for both;
+// otherwise deleting the old cert drops the printer off the authenticated
+// network. If the model doesn't support 802.1X, ErrUnsupported is returned.
+func (p *printer) SetDot1xCert(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	ctx = p.dryRunContext(ctx, "set 802.1X cert", id)
+	err := p.setCertSelectOnPage(ctx, serviceCertPages[ServiceDot1x], id)
+	if err != nil {
+		return fmt.Errorf("printer: failed to set 802.1X cert (%w)", err)
+	}
+
+	// dry run? (the submission was planned)
+	if plan := dryRunPlan(ctx); plan != nil {
+		return plan
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package printer
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const urlCertExport = "/net/security/certificate/export.html"
+
+var (
+	errCertExportNotFound = errors.New("printer: export: cert not found in export response")
+
+	// e.g. `-----BEGIN CERTIFICATE-----...-----END CERTIFICATE-----`
+	regexCertPem = regexp.MustCompile(`(?s)-----BEGIN CERTIFICATE-----.*?-----END CERTIFICATE-----`)
+)
+
+// parseCertExport returns the cert contained in an export response, which is
+// either pem (possibly within a page) or der, as pem
+func parseCertExport(bodyBytes []byte) ([]byte, bool) {
+	// pem
+	certPem := regexCertPem.Find([]byte(html.UnescapeString(string(bodyBytes))))
+	if certPem != nil {
+		block, _ := pem.Decode(certPem)
+		if block == nil {
+			return nil, false
+		}
+
+		_, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, false
+		}
+
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes}), true
+	}
+
+	// der
+	_, err := x509.ParseCertificate(bodyBytes)
+	if err == nil {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: bodyBytes}), true
+	}
+
+	return nil, false
+}
+
+// ExportCert downloads the public certificate (not the private key) with the
+// specified ID from the printer, as pem. If the model can't export certs,
+// ErrUnsupported is returned.
+func (p *printer) ExportCert(id string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	var certPem []byte
+	err := p.withSessionRetry(ctx, func(ctx context.Context) error {
+		var err error
+		certPem, err = p.exportCert(ctx, id)
+		return err
+	})
+
+	return certPem, err
+}
+
+// exportCert performs ExportCert using ctx
+func (p *printer) exportCert(ctx context.Context, id string) ([]byte, error) {
+	// the export page of a missing cert is not necessarily an error page
+	existingIDs, err := p.getCertIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(existingIDs, id) {
+		return nil, fmt.Errorf("%w (id: %s)", ErrCertNotFound, id)
+	}
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = p.urlPath(urlCertExport)
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// req query
+	query := req.URL.Query()
+	query.Set("idx", id)
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// page doesn't exist on this model?
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w (page %s)", ErrUnsupported, urlCertExport)
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPStatusError("get of export page", resp)
+	}
+
+	// some models download the cert directly
+	certPem, ok := parseCertExport(bodyBytes)
+	if ok {
+		return certPem, nil
+	}
+
+	p.logForm(ctx, urlCertExport, bodyBytes)
+
+	// otherwise submit the export form as is (without a password, which is
+	// only for exporting the key)
+	data := parseFormValues(bodyBytes)
+	if !hasCSRFToken(data) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return nil, err
+		}
+		data.Set(csrfToken.name, csrfToken.value)
+	}
+	data.Set("hidden_certificate_idx", id)
+
+	// make and do request
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err = p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPStatusError("post of export form", resp)
+	}
+
+	certPem, ok = parseCertExport(bodyBytes)
+	if !ok {
+		return nil, errCertExportNotFound
+	}
+
+	return certPem, nil
+}
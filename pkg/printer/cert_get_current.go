@@ -2,16 +2,17 @@ package printer
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"html"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -20,18 +21,118 @@ const (
 	urlCertView = "/net/security/certificate/view.html"
 )
 
+var errCertSerialNotFound = errors.New("printer: no cert with matching serial found on printer")
+
+// maxCertListPages limits how many pages of the certificate list are followed
+const maxCertListPages = 50
+
+// certListPageParams are the query parameters that number the pages of the
+// certificate list, e.g. `certificate.html?page=2`
+var certListPageParams = []string{"page", "pageno", "pg", "p"}
+
+// e.g. `Next &gt;` or `»` (the text of a link to the next page)
+var regexCertListNextLink = regexp.MustCompile(`(?i)^(?:next\b.*|>|>>|»|›)$`)
+
 // getCertIDs loads the certificate page and parses it to obtain the
-// IDs of the existing certificates
-func (p *printer) getCertIDs() ([]string, error) {
+// IDs of the existing certificates. if the list is paginated, all of its
+// pages are loaded.
+func (p *printer) getCertIDs(ctx context.Context) ([]string, error) {
+	pageBodies, err := p.getCertListPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	seenIDs := make(map[string]struct{})
+	for _, bodyBytes := range pageBodies {
+		for _, id := range parseCertIDs(bodyBytes) {
+			if _, seen := seenIDs[id]; !seen {
+				seenIDs[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// getCertListPages fetches all of the pages of the certificate list
+func (p *printer) getCertListPages(ctx context.Context) ([][]byte, error) {
+	pageBodies := [][]byte{}
+
+	// follow each numbered page once (links that only sort or filter the
+	// list aren't followed)
+	seenPages := map[int]struct{}{1: {}}
+	pages := []certListPageLink{{page: 1}}
+	for i := 0; i < len(pages) && i < maxCertListPages; i++ {
+		bodyBytes, err := p.getCertListPage(ctx, pages[i].query)
+		if err != nil {
+			return nil, err
+		}
+		pageBodies = append(pageBodies, bodyBytes)
+
+		for _, link := range parseCertListPageLinks(bodyBytes, pages[i].page) {
+			if _, seen := seenPages[link.page]; !seen {
+				seenPages[link.page] = struct{}{}
+				pages = append(pages, link)
+			}
+		}
+	}
+
+	return pageBodies, nil
+}
+
+// certListPageLink is a link to a page of the certificate list
+type certListPageLink struct {
+	page  int
+	query string
+}
+
+// parseCertListPageLinks returns the links to other pages of the certificate
+// list, which is showing the specified page. a link is to a page if it has a
+// page number, or is a next link (the page after the current one).
+func parseCertListPageLinks(bodyBytes []byte, currentPage int) []certListPageLink {
+	links := []certListPageLink{}
+	for _, anchor := range parseElements(bodyBytes, "a") {
+		href, err := url.Parse(anchor.attrs["href"])
+		if err != nil || !strings.HasSuffix(href.Path, "certificate.html") || href.RawQuery == "" {
+			continue
+		}
+
+		page := 0
+		for _, param := range certListPageParams {
+			n, err := strconv.Atoi(href.Query().Get(param))
+			if err == nil && n > 0 {
+				page = n
+				break
+			}
+		}
+		if page == 0 && regexCertListNextLink.MatchString(anchor.text(bodyBytes)) {
+			page = currentPage + 1
+		}
+		if page == 0 {
+			continue
+		}
+
+		links = append(links, certListPageLink{page: page, query: href.RawQuery})
+	}
+
+	return links
+}
+
+// getCertListPage fetches the certificate list page with the specified raw
+// query (e.g. a page number), which may be empty
+func (p *printer) getCertListPage(ctx context.Context, rawQuery string) ([]byte, error) {
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return nil, err
 	}
-	u.Path = urlCertList
+	u.Path = p.urlPath(urlCertList)
+	u.RawQuery = rawQuery
 
 	// make and do request
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -48,42 +149,60 @@ func (p *printer) getCertIDs() ([]string, error) {
 		return nil, err
 	}
 
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("printer: get of certificate list page failed (status code %d)", resp.StatusCode)
+		return nil, newHTTPStatusError("get of certificate list page", resp)
 	}
 
+	return bodyBytes, nil
+}
+
+// parseCertIDs parses the IDs of the certificates from the certificate list
+// page
+func parseCertIDs(bodyBytes []byte) []string {
 	// parse IDs
 	// e.g. `<td><a href="view.html?idx=58">View</a></td>`
-	regex := regexp.MustCompile(`<a[^>]+href="view\.html\?idx=([^"]+)"[^>]*>`)
-	caps := regex.FindAllSubmatch(bodyBytes, -1)
-
-	// range through matches and get capture group (the actual ID)
 	ids := []string{}
-	for i := range caps {
-		// if match is somehow the wrong length, skip it
-		if len(caps[i]) != 2 {
-			continue
+	for _, anchor := range parseElements(bodyBytes, "a") {
+		id, ok := parseCertViewLinkID(anchor.attrs["href"])
+		if ok {
+			ids = append(ids, id)
 		}
+	}
+
+	return ids
+}
 
-		ids = append(ids, string(caps[i][1]))
+// parseCertViewLinkID returns the cert ID of an anchor's href that links to a
+// cert's view page
+func parseCertViewLinkID(link string) (string, bool) {
+	href, err := url.Parse(link)
+	if err != nil || !strings.HasSuffix(href.Path, "view.html") {
+		return "", false
 	}
 
-	return ids, nil
+	id := href.Query().Get("idx")
+	return id, id != ""
 }
 
-// getCertgetCertIDSerialIDs loads the certificate view page and parses the
-// cert's serial number hex string into hex data
-func (p *printer) getCertIDSerial(id string) ([]byte, error) {
+// getCertViewPage loads the view page of the certificate with the specified
+// ID
+func (p *printer) getCertViewPage(ctx context.Context, id string) ([]byte, error) {
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return nil, err
 	}
-	u.Path = urlCertView
+	u.Path = p.urlPath(urlCertView)
 
 	// make request
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -106,14 +225,31 @@ func (p *printer) getCertIDSerial(id string) ([]byte, error) {
 		return nil, err
 	}
 
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("printer: get certificate view page failed (status code %d)", resp.StatusCode)
+		return nil, newHTTPStatusError("get of certificate view page", resp)
+	}
+
+	return bodyBytes, nil
+}
+
+// getCertgetCertIDSerialIDs loads the certificate view page and parses the
+// cert's serial number hex string into hex data
+func (p *printer) getCertIDSerial(ctx context.Context, id string) ([]byte, error) {
+	bodyBytes, err := p.getCertViewPage(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
 	// parse Serial Number string
 	// e.g. `<dt>Serial&#32;Number</dt><dd>06:22:61:1a:32:3a:f8:ea:5b:be:3f:6c:53:a2:1e:d2:a4:c4</dd><dt>Issuer</dt>`
-	regex := regexp.MustCompile(`<dt>Serial(?:\s|&#32;)Number</dt><dd>([A-Za-z0-9:]+)</dd>`)
+	regex := regexp.MustCompile(`(?is)<dt[^>]*>\s*Serial(?:\s|&#32;|&nbsp;)+Number\s*</dt>\s*<dd[^>]*>\s*([A-Za-z0-9:]+)\s*</dd>`)
 	caps := regex.FindSubmatch(bodyBytes)
 
 	if len(caps) < 2 {
@@ -166,63 +302,77 @@ func (p *printer) getCertIDSerial(id string) ([]byte, error) {
 // certificate ID as it definitively only requires one page load; however, this may not always
 // work as at least some printers do not list certificates without a Common Name, even if said
 // certificate is currently active
-func (p *printer) getCurrentCertIDFromHttpSettings() (id string, name string, err error) {
+func (p *printer) getCurrentCertIDFromHttpSettings(ctx context.Context) (id string, name string, err error) {
 	// GET http settings
-	bodyBytes, err := p.getHttpSettings()
+	bodyBytes, err := p.getHttpSettings(ctx)
 	if err != nil {
 		return "", "", err
 	}
 
 	// find the selected cert in the returned html
 	// e.g. `<option value="3" selected="selected">xxx</option>`
-	regex := regexp.MustCompile(`<option[^>]+(?:value="([^"]+)"[^>]+selected="selected"[^>]*|selected="selected"[^>]+value="([^"]+)"[^>]*)>(\S*)<\/option>`)
-	caps := regex.FindSubmatch(bodyBytes)
-
-	// len must be 4 ([0] is the entire match)
-	if len(caps) != 4 {
-		return "", "", errCurrentCertIdNotFound
+	fields, err := parseHttpSettingsFormFields(bodyBytes)
+	if err != nil {
+		return "", "", err
 	}
 
-	// first capture opportunity for id
-	id = ""
-	if len(caps[1]) != 0 {
-		id = string(caps[1])
-	}
+	for _, sel := range parseSelects(bodyBytes) {
+		if sel.attrs["name"] != fields.certSelectField {
+			continue
+		}
 
-	// second capture opportunity for id
-	if id == "" && len(caps[2]) != 0 {
-		id = string(caps[2])
-	}
+		for _, option := range sel.options {
+			if _, selected := option.attrs["selected"]; !selected || option.attrs["value"] == "" {
+				continue
+			}
 
-	// verify valid id obtained
-	if id == "" {
-		return "", "", errCurrentCertIdNotFound
+			// name is the option's text (htmlToText unescapes char codes)
+			return option.attrs["value"], option.text(bodyBytes), nil
+		}
 	}
 
-	// name will be in html char codes, so unescape it
-	return id, html.UnescapeString(string(caps[3])), nil
+	return "", "", errCurrentCertIdNotFound
 }
 
 // GetCurrentLeafCert() returns the current Certificate that is being used by the
 // printer for SSL connections. This is achieved by performing a TLS handshake
 // with the printer
 func (p *printer) GetCurrentLeafCert() (*x509.Certificate, error) {
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.getCurrentLeafCert(ctx)
+}
+
+// getCurrentLeafCert performs GetCurrentLeafCert using ctx
+func (p *printer) getCurrentLeafCert(ctx context.Context) (*x509.Certificate, error) {
+	// get host (baseUrl may be http, but the handshake is always https)
+	hostname, addr, err := p.httpsAddr()
+	if err != nil {
+		return nil, err
+	}
+
 	// use tls handshake to get the serial of the active certificate
-	conf := &tls.Config{
-		InsecureSkipVerify: true,
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         hostname,
+		},
 	}
 
-	conn, err := tls.Dial("tcp", strings.TrimPrefix(p.baseUrl, "https://")+":443", conf)
+	conn, err := dialer.DialContext(ctx, "tcp", p.transport.dialAddr(addr))
 	if err != nil {
 		return nil, fmt.Errorf("printer: failed to perform tls handshake with printer (dial failed: %s)", err)
 	}
 	defer conn.Close()
 
-	certs := conn.ConnectionState().PeerCertificates
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
 	if len(certs) <= 0 {
 		return nil, errors.New("printer: failed to get ssl cert from printer")
 	}
 
+	p.transport.setLastServerCert(certs[0])
+
 	return certs[0], nil
 }
 
@@ -232,42 +382,64 @@ func (p *printer) GetCurrentLeafCert() (*x509.Certificate, error) {
 // NOTE: If there is more than one copy of the active cert on the printer (which is possible
 // if you upload the same cert twice), it is not possible to distinguish which is which and
 // only one will be deleted.
-func (p *printer) getCurrentCertIDFromCertList() (id string, err error) {
+func (p *printer) getCurrentCertIDFromCertList(ctx context.Context) (id string, err error) {
 	// get currently in use cert
-	leafCert, err := p.GetCurrentLeafCert()
+	leafCert, err := p.getCurrentLeafCert(ctx)
 	if err != nil {
 		return "", err
 	}
 
+	id, err = p.findCertIDBySerial(ctx, leafCert.SerialNumber.Bytes())
+	if errors.Is(err, errCertSerialNotFound) {
+		return "", fmt.Errorf("printer: get current id from cert list failed (no serial match)")
+	}
+
+	return id, err
+}
+
+// findCertIDBySerial returns the ID of the (first) cert on the printer that
+// has the specified serial
+func (p *printer) findCertIDBySerial(ctx context.Context, serial []byte) (id string, err error) {
 	// get the list of all certs on the printer
-	printerCertIDs, err := p.getCertIDs()
+	printerCertIDs, err := p.getCertIDs(ctx)
 	if err != nil {
 		return "", fmt.Errorf("printer: failed to get ssl cert list from printer (%s)", err)
 	}
 
 	// for each printer cert id, fetch its view page, parse the serial, and compare it against
-	// the serial acquired during the tls handshake
+	// the specified serial
 	for _, certID := range printerCertIDs {
-		certSerial, err := p.getCertIDSerial(certID)
+		certSerial, err := p.getCertIDSerial(ctx, certID)
 		if err != nil {
 			// failed? keep trying other options
 			continue
 		}
 
 		// if serials match, return the id
-		if bytes.EqualFold(certSerial, leafCert.SerialNumber.Bytes()) {
+		if bytes.EqualFold(certSerial, serial) {
 			return certID, nil
 		}
 	}
 
-	return "", fmt.Errorf("printer: get current id from cert list failed (no serial match)")
+	return "", errCertSerialNotFound
 }
 
 // GetCurrentCertID returns the ID integer and name of the currently selected
 // certificate
 func (p *printer) GetCurrentCertID() (id string, name string, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.getCurrentCertID(ctx)
+}
+
+// getCurrentCertID performs GetCurrentCertID using ctx
+func (p *printer) getCurrentCertID(ctx context.Context) (id string, name string, err error) {
 	// try the "easy" method first
-	id, name, err = p.getCurrentCertIDFromHttpSettings()
+	id, name, err = p.getCurrentCertIDFromHttpSettings(ctx)
 	// NOTE: Inverted error check!
 	if err == nil {
 		return id, name, nil
@@ -278,10 +450,57 @@ func (p *printer) GetCurrentCertID() (id string, name string, err error) {
 		return "", "", errors.New("printer: get current cert id failed (not in http settings list and https isn't available)")
 	}
 
-	id, err = p.getCurrentCertIDFromCertList()
+	id, err = p.getCurrentCertIDFromCertList(ctx)
 	if err != nil {
 		return "", "", err
 	}
 
 	return id, "[no name]", err
 }
+
+// GetActiveCert returns the ID of the cert currently selected on the http
+// settings page (i.e. the cert the printer uses for https). Certs without a
+// Common Name aren't listed there, so if none is selected (and the printer is
+// https), the served cert is matched by serial instead.
+func (p *printer) GetActiveCert() (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.getActiveCert(ctx)
+}
+
+// getActiveCert performs GetActiveCert using ctx
+func (p *printer) getActiveCert(ctx context.Context) (string, error) {
+	// GET http settings
+	bodyBytes, err := p.getHttpSettings(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// find form fields
+	fields, err := parseHttpSettingsFormFields(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	id, ok := parseSelectedOption(bodyBytes, fields.certSelectField)
+	if ok && id != "" {
+		return id, nil
+	}
+
+	// certs without a Common Name aren't in the select; match the served
+	// cert's serial instead
+	if !strings.HasPrefix(strings.ToLower(p.baseUrl), "https://") {
+		return "", errCurrentCertIdNotFound
+	}
+
+	id, err = p.getCurrentCertIDFromCertList(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w (%s)", errCurrentCertIdNotFound, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,95 @@
+package printer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// certListPage1 and certListPage2 are a certificate list split over two
+// pages, each linking to the other
+const (
+	certListPage1 = `<html><body><table>
+<tr><th>Certificate Name</th><th>Issuer</th></tr>
+<tr><td>printer-a</td><td>ca</td><td><a href="view.html?idx=11">View</a></td></tr>
+<tr><td>printer-b</td><td>ca</td><td><a href="view.html?idx=12">View</a></td></tr>
+</table>
+<a href="certificate.html?page=2">Next &gt;</a>
+</body></html>`
+
+	certListPage2 = `<html><body><table>
+<tr><th>Certificate Name</th><th>Issuer</th></tr>
+<tr><td>printer-b</td><td>ca</td><td><a href="view.html?idx=12">View</a></td></tr>
+<tr><td>printer-c</td><td>ca</td><td><a href='view.html?idx=13'>View</a></td></tr>
+</table>
+<a href="/net/security/certificate/certificate.html?page=1&amp;sort=name">&lt; Prev</a>
+</body></html>`
+)
+
+func TestParseCertListPageLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []certListPageLink
+	}{
+		{"relative", certListPage1, []certListPageLink{{2, "page=2"}}},
+		{"absolute and escaped", certListPage2, []certListPageLink{{1, "page=1&sort=name"}}},
+		{"sort and filter links", `<a href="certificate.html?sort=name">Name</a><a href="certificate.html?col=issuer">Issuer</a>`, []certListPageLink{}},
+		{"next without page number", `<a href="certificate.html?from=11">Next &gt;</a>`, []certListPageLink{{4, "from=11"}}},
+		{"none", `<a href="view.html?idx=5">View</a>`, []certListPageLink{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCertListPageLinks([]byte(tt.body), 3)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseCertListPageLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCertIDsTwoPages(t *testing.T) {
+	requests := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != urlCertList {
+			http.NotFound(w, r)
+			return
+		}
+		requests = append(requests, r.URL.RawQuery)
+
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			_, _ = w.Write([]byte(certListPage1))
+		case "2":
+			_, _ = w.Write([]byte(certListPage2))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL, WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ids, err := p.getCertIDs(context.Background())
+	if err != nil {
+		t.Fatalf("getCertIDs() error = %v", err)
+	}
+
+	want := []string{"11", "12", "13"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("getCertIDs() = %v, want %v", ids, want)
+	}
+
+	// first page and page 2 (the 'prev' link is page 1 again, even though its
+	// query differs)
+	wantRequests := []string{"", "page=2"}
+	if !slices.Equal(requests, wantRequests) {
+		t.Errorf("requested pages %v, want %v", requests, wantRequests)
+	}
+}
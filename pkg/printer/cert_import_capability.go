@@ -0,0 +1,66 @@
+package printer
+
+import (
+	"context"
+)
+
+// ImportFormat is the format of key and cert that the printer's certificate
+// import page accepts
+type ImportFormat int
+
+const (
+	// ImportFormatPfx is a single p12 (pfx) file containing the key and cert,
+	// with an optional password (most models)
+	ImportFormatPfx ImportFormat = iota
+	// ImportFormatPem is separate pem files for the cert and the key
+	ImportFormatPem
+)
+
+// String returns the name of the import format
+func (format ImportFormat) String() string {
+	switch format {
+	case ImportFormatPfx:
+		return "pfx"
+	case ImportFormatPem:
+		return "pem"
+	default:
+		return "unknown"
+	}
+}
+
+// parseImportFormat determines which ImportFormat the import page expects
+// based on its file fields
+func parseImportFormat(bodyBytes []byte) (ImportFormat, error) {
+	fileInputs := parseFileInputs(bodyBytes)
+
+	switch {
+	case len(fileInputs) >= 2:
+		// separate cert and key files
+		return ImportFormatPem, nil
+
+	case len(fileInputs) == 1:
+		// single p12 file (and its password)
+		return ImportFormatPfx, nil
+
+	default:
+		return 0, errFileFieldNotFound
+	}
+}
+
+// ImportCapability fetches the printer's certificate import page and returns
+// the format of key and cert it accepts. UploadNewCert does this automatically,
+// but it is exposed so callers can prepare their key and cert accordingly.
+func (p *printer) ImportCapability() (ImportFormat, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	bodyBytes, err := p.getImportPage(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseImportFormat(bodyBytes)
+}
@@ -0,0 +1,300 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// e.g. `<th>Issuer</th>`
+	regexTableHeader = regexp.MustCompile(`(?is)<th[^>]*>(.*?)</th>`)
+	// e.g. `2025/09/09 - 2026/09/09` (a single validity period column)
+	regexDateRange = regexp.MustCompile(`^(.+?)\s+(?:-|~|to)\s+(.+)$`)
+	// e.g. `Yes`, `✓`, or `○` (an affirmative private key cell)
+	regexPrivateKeyYes = regexp.MustCompile(`(?i)^(?:yes|true|on|available|installed|[✓✔○●])$`)
+)
+
+// certTimeLayouts are the formats the certificate list uses for dates
+var certTimeLayouts = []string{
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"Jan _2 15:04:05 2006 MST",
+	"Jan _2 15:04:05 2006",
+	"01/02/2006",
+}
+
+// certListColumn is a column of the certificate list table
+type certListColumn int
+
+const (
+	certListColumnUnknown certListColumn = iota
+	certListColumnName
+	certListColumnIssuer
+	certListColumnNotBefore
+	certListColumnNotAfter
+	certListColumnValidity
+	certListColumnSerial
+	certListColumnPrivateKey
+)
+
+// CertInfo is the metadata of a certificate, as shown in the printer's
+// certificate list. Fields the list doesn't show are empty. CertInfo (and a
+// slice of them, as returned by ListCerts) marshal to JSON with RFC3339 dates,
+// omitting fields the list doesn't show, e.g.:
+//
+//	certs, err := p.ListCerts()
+//	if err != nil {
+//		return err
+//	}
+//	return json.NewEncoder(os.Stdout).Encode(certs)
+type CertInfo struct {
+	ID         string    `json:"id"`
+	CommonName string    `json:"common_name,omitempty"`
+	Issuer     string    `json:"issuer,omitempty"`
+	NotBefore  time.Time `json:"not_before,omitzero"`
+	NotAfter   time.Time `json:"not_after,omitzero"`
+	Serial     string    `json:"serial,omitempty"`
+
+	// DisplayName is the CommonName or, if the cert has none (e.g. a cert
+	// with only SANs), its first DNS SAN
+	DisplayName string `json:"display_name,omitempty"`
+
+	// HasPrivateKey is true if the printer has the cert's private key (i.e.
+	// it can be used as the printer's https identity). If the list doesn't
+	// show it, it is assumed to be true (ca certs are in a separate list).
+	HasPrivateKey bool `json:"has_private_key"`
+}
+
+// firstDNSName returns the first DNS name in the SANs shown on a cert's view
+// page (e.g. `DNS:printer.example.com`), or "" if there is none
+func firstDNSName(sans []string) string {
+	for _, san := range sans {
+		kind, name, found := strings.Cut(san, ":")
+		if !found {
+			// no type shown; skip IPs
+			if net.ParseIP(san) == nil {
+				return san
+			}
+			continue
+		}
+
+		if strings.EqualFold(strings.TrimSpace(kind), "DNS") {
+			return strings.TrimSpace(name)
+		}
+	}
+
+	return ""
+}
+
+// certListColumnFromHeader returns the column type from its header text
+func certListColumnFromHeader(header string) certListColumn {
+	header = strings.ToLower(header)
+
+	switch {
+	case strings.Contains(header, "private key"), header == "key":
+		return certListColumnPrivateKey
+	case strings.Contains(header, "issuer"):
+		return certListColumnIssuer
+	case strings.Contains(header, "serial"):
+		return certListColumnSerial
+	case strings.Contains(header, "not before"), strings.Contains(header, "valid from"), strings.Contains(header, "start"):
+		return certListColumnNotBefore
+	case strings.Contains(header, "not after"), strings.Contains(header, "valid to"), strings.Contains(header, "expir"):
+		return certListColumnNotAfter
+	case strings.Contains(header, "validity"):
+		return certListColumnValidity
+	case strings.Contains(header, "name"), strings.Contains(header, "subject"):
+		return certListColumnName
+	default:
+		return certListColumnUnknown
+	}
+}
+
+// parseCertTime parses a date from the certificate list as UTC
+func parseCertTime(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range certTimeLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, errors.New("printer: failed to parse cert list date")
+}
+
+// parsePrivateKeyCell returns true if the private key cell of the cert list
+// shows that the cert has a private key, either as text or as an icon (whose
+// alt text, title, or image name mentions a key, but not its absence)
+func parsePrivateKeyCell(cell []byte) bool {
+	if regexPrivateKeyYes.MatchString(htmlToText(cell)) {
+		return true
+	}
+
+	// e.g. `<img src="key.gif" alt="Private Key">`
+	for _, img := range parseElements(cell, "img") {
+		attrs := img.attrs
+		for _, attr := range []string{"alt", "title", "src"} {
+			value := strings.ToLower(attrs[attr])
+			if (strings.Contains(value, "key") && !strings.Contains(value, "no")) || regexPrivateKeyYes.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseCertInfoRow parses a row of the certificate list using the column
+// types from the table's header
+func parseCertInfoRow(row []byte, columns []certListColumn) (CertInfo, error) {
+	id := ""
+	for _, anchor := range parseElements(row, "a") {
+		var ok bool
+		if id, ok = parseCertViewLinkID(anchor.attrs["href"]); ok {
+			break
+		}
+	}
+	if id == "" {
+		return CertInfo{}, errors.New("printer: cert list row has no id")
+	}
+	// certs are assumed to have a key unless the list says otherwise
+	info := CertInfo{
+		ID:            id,
+		HasPrivateKey: !slices.Contains(columns, certListColumnPrivateKey),
+	}
+
+	cells := regexTableCell.FindAllSubmatch(row, -1)
+	for i := range cells {
+		if i >= len(columns) {
+			break
+		}
+
+		// key is often shown as an icon
+		if columns[i] == certListColumnPrivateKey {
+			info.HasPrivateKey = parsePrivateKeyCell(cells[i][1])
+			continue
+		}
+
+		text := htmlToText(cells[i][1])
+		if text == "" {
+			continue
+		}
+
+		var err error
+		switch columns[i] {
+		case certListColumnName:
+			info.CommonName = text
+		case certListColumnIssuer:
+			info.Issuer = text
+		case certListColumnSerial:
+			info.Serial = text
+		case certListColumnNotBefore:
+			info.NotBefore, err = parseCertTime(text)
+		case certListColumnNotAfter, certListColumnValidity:
+			// either the expiration alone or a range
+			dates := regexDateRange.FindStringSubmatch(text)
+			if len(dates) == 3 {
+				info.NotBefore, err = parseCertTime(dates[1])
+				if err == nil {
+					info.NotAfter, err = parseCertTime(dates[2])
+				}
+			} else {
+				info.NotAfter, err = parseCertTime(text)
+			}
+		}
+		if err != nil {
+			return CertInfo{}, err
+		}
+	}
+
+	return info, nil
+}
+
+// parseCertInfos parses the rows of the certificate list page. rows that
+// fail to parse are skipped.
+func parseCertInfos(bodyBytes []byte) []CertInfo {
+	infos := []CertInfo{}
+
+	columns := []certListColumn{}
+	for _, row := range regexTableRow.FindAllSubmatch(bodyBytes, -1) {
+		// header row
+		headers := regexTableHeader.FindAllSubmatch(row[1], -1)
+		if len(headers) > 0 {
+			columns = []certListColumn{}
+			for _, header := range headers {
+				columns = append(columns, certListColumnFromHeader(htmlToText(header[1])))
+			}
+			continue
+		}
+
+		info, err := parseCertInfoRow(row[1], columns)
+		if err != nil {
+			continue
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// ListCerts returns the metadata of the certificates on the printer, as shown
+// in its certificate list. Dates are in UTC.
+func (p *printer) ListCerts() ([]CertInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.listCerts(ctx)
+}
+
+// listCerts performs ListCerts using ctx
+func (p *printer) listCerts(ctx context.Context) ([]CertInfo, error) {
+	pageBodies, err := p.getCertListPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []CertInfo{}
+	seenIDs := make(map[string]struct{})
+	for _, bodyBytes := range pageBodies {
+		for _, info := range parseCertInfos(bodyBytes) {
+			if _, seen := seenIDs[info.ID]; !seen {
+				seenIDs[info.ID] = struct{}{}
+				infos = append(infos, info)
+			}
+		}
+	}
+
+	// display name; certs without a Common Name need their SANs, which are
+	// only on their view page
+	for i := range infos {
+		infos[i].DisplayName = infos[i].CommonName
+		if infos[i].DisplayName != "" {
+			continue
+		}
+
+		bodyBytes, err := p.getCertViewPage(ctx, infos[i].ID)
+		if err != nil {
+			continue
+		}
+		details, err := parseCertDetails(infos[i].ID, bodyBytes)
+		if err != nil {
+			continue
+		}
+		infos[i].DisplayName = firstDNSName(details.SANs)
+	}
+
+	return infos, nil
+}
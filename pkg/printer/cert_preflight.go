@@ -0,0 +1,99 @@
+package printer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrKeyCertMismatch is returned when the private key to upload isn't the
+	// key of the cert's public key. The printer would accept the upload but
+	// not install a usable cert.
+	ErrKeyCertMismatch = errors.New("printer: upload: private key does not match cert")
+
+	// ErrCertExpired is returned when the cert to upload has already expired
+	ErrCertExpired = errors.New("printer: upload: cert has expired")
+)
+
+// parsePrivateKeyPem returns the private key (of any type) from keyPem
+func parsePrivateKeyPem(keyPem []byte) (crypto.Signer, error) {
+	keyPemBlock, _ := pem.Decode(keyPem)
+	if keyPemBlock == nil {
+		return nil, errors.New("printer: key pem block did not decode")
+	}
+
+	var key any
+	var err error
+	switch keyPemBlock.Type {
+	case "RSA PRIVATE KEY": // PKCS1
+		key, err = x509.ParsePKCS1PrivateKey(keyPemBlock.Bytes)
+	case "EC PRIVATE KEY": // SEC1
+		key, err = x509.ParseECPrivateKey(keyPemBlock.Bytes)
+	case "PRIVATE KEY": // PKCS8
+		key, err = x509.ParsePKCS8PrivateKey(keyPemBlock.Bytes)
+	default:
+		return nil, fmt.Errorf("printer: unsupported key pem block type '%s'", keyPemBlock.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errUnsupportedKey
+	}
+
+	return signer, nil
+}
+
+// checkKeyCertPair parses keyPem and certPem and returns ErrKeyCertMismatch
+// if the key doesn't belong to the (leaf) cert, ErrUnsupportedCurve if the key
+// is on a curve the printer rejects, or ErrCertExpired if the cert has
+// expired. If warnWithin is more than 0 and the cert expires within it,
+// warn is called with the cert's expiration.
+func checkKeyCertPair(keyPem, certPem []byte, warnWithin time.Duration, warn func(notAfter time.Time)) error {
+	key, err := parsePrivateKeyPem(keyPem)
+	if err != nil {
+		return fmt.Errorf("printer: upload: failed to parse key (%w)", err)
+	}
+
+	// refuse curves the printer rejects before contacting it
+	if ecKey, ok := key.(*ecdsa.PrivateKey); ok {
+		err = checkKeyCurve(ecKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	certPemBlock, _ := pem.Decode(certPem)
+	if certPemBlock == nil {
+		return errors.New("printer: upload: failed to decode cert pem")
+	}
+
+	cert, err := x509.ParseCertificate(certPemBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("printer: upload: failed to parse cert (%w)", err)
+	}
+
+	// all std lib public keys implement Equal
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return ErrKeyCertMismatch
+	}
+
+	now := time.Now()
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("%w (expired %s)", ErrCertExpired, cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	if warnWithin > 0 && warn != nil && cert.NotAfter.Sub(now) < warnWithin {
+		warn(cert.NotAfter)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCertNoPrivateKey is returned when activating a cert that the printer
+// doesn't have the private key of (e.g. an imported public-only cert), which
+// can't be used as the printer's https identity
+var ErrCertNoPrivateKey = errors.New("printer: cert has no private key on the printer")
+
+// checkCertHasPrivateKey returns ErrCertNoPrivateKey if the cert list shows
+// that the cert with the specified id has no private key. If the list can't be
+// read or doesn't include the cert, nil is returned (so activating proceeds
+// as before).
+func (p *printer) checkCertHasPrivateKey(ctx context.Context, id string) error {
+	infos, err := p.listCerts(ctx)
+	if err != nil {
+		p.logger.DebugContext(ctx, "printer: failed to check cert for private key", "error", err)
+		return nil
+	}
+
+	for _, info := range infos {
+		if info.ID == id && !info.HasPrivateKey {
+			return fmt.Errorf("%w (id: %s, name: %s)", ErrCertNoPrivateKey, id, info.DisplayName)
+		}
+	}
+
+	return nil
+}
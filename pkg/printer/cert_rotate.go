@@ -0,0 +1,127 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultRotateVerifyTimeout is how long RotateCert waits for the printer to
+// serve the new cert after rebooting, by default
+const defaultRotateVerifyTimeout = 3 * time.Minute
+
+var errRotateDeleteNeedsReboot = errors.New("printer: rotate: deleting the old cert requires the printer to reboot (to stop using it)")
+
+// ErrRotateRolledBack is returned by RotateCert when the printer didn't serve
+// the new cert after activating it, so the previously active cert was
+// activated again. The printer is left serving the old cert (and the new cert
+// is still installed).
+var ErrRotateRolledBack = errors.New("printer: rotate: new cert not served, rolled back to old cert")
+
+// RotateOptions modifies the behavior of RotateCert
+type RotateOptions struct {
+	// DeleteOld deletes the previously active cert once the new cert is
+	// confirmed to be served. The preset cert (id 0) is never deleted.
+	DeleteOld bool
+
+	// SkipReboot activates the new cert without rebooting (see
+	// SetActiveCertOptions.SkipReboot), so it is not verified (or the old
+	// cert deleted) until RebootPrinter is called. It can't be used with
+	// DeleteOld.
+	SkipReboot bool
+
+	// VerifyTimeout is how long to wait for the printer to serve the new cert
+	// after rebooting (default 3 minutes)
+	VerifyTimeout time.Duration
+
+	// Upload and Activate are the options of the upload and activation steps.
+	// Activate's SkipReboot and AutoRollback are set by RotateCert.
+	Upload   UploadOptions
+	Activate SetActiveCertOptions
+}
+
+// RotateCert replaces the printer's active cert: it uploads the specified key
+// and cert, activates the new cert (rebooting the printer), verifies the
+// printer serves it, and (if set) deletes the previously active cert. If the
+// printer doesn't serve the new cert in time, the old cert is activated again
+// (rolled back) and an error wrapping ErrRotateRolledBack (and the verify
+// error) is returned. newID is returned if the new cert was uploaded, even if
+// a later step failed.
+func (p *printer) RotateCert(keyPem, certPem []byte, opts RotateOptions) (newID string, err error) {
+	if opts.DeleteOld && opts.SkipReboot {
+		return "", errRotateDeleteNeedsReboot
+	}
+	if opts.VerifyTimeout <= 0 {
+		opts.VerifyTimeout = defaultRotateVerifyTimeout
+	}
+
+	// fingerprint to verify the new cert is served
+	cert, _, err := certPemToCerts(certPem)
+	if err != nil {
+		return "", fmt.Errorf("printer: rotate: failed to parse cert (%w)", err)
+	}
+	fingerprint := certFingerprint(cert)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	// currently active cert (to roll back to, and delete)
+	oldID, err := p.getActiveCert(ctx)
+	if err != nil {
+		return "", fmt.Errorf("printer: rotate: failed to get current cert (%w)", err)
+	}
+
+	// upload
+	newID, err = p.uploadNewCertWithRetries(ctx, keyPem, certPem, nil, opts.Upload)
+	if err != nil {
+		return "", fmt.Errorf("printer: rotate: upload failed (%w)", err)
+	}
+
+	// activate
+	activateOpts := opts.Activate
+	activateOpts.SkipReboot = opts.SkipReboot
+	activateOpts.AutoRollback = false
+	err = p.setActiveCert(p.dryRunContext(ctx, "set active cert", newID), newID, activateOpts)
+	if err != nil {
+		return newID, fmt.Errorf("printer: rotate: failed to activate new cert (id: %s) (%w)", newID, err)
+	}
+
+	// not applied until the reboot
+	if opts.SkipReboot {
+		return newID, nil
+	}
+
+	err = p.waitForOnline(ctx)
+	if err != nil {
+		return newID, fmt.Errorf("printer: rotate: new cert (id: %s) activated but printer did not come back online (%w)", newID, err)
+	}
+
+	// verify, else roll back
+	verifyCtx, cancelVerify := context.WithTimeout(ctx, opts.VerifyTimeout)
+	lastResult, err := p.awaitServedCert(verifyCtx, fingerprint, func(int) time.Duration { return awaitActiveCertInterval })
+	cancelVerify()
+	if err != nil {
+		rollbackErr := p.setActiveCert(ctx, oldID, SetActiveCertOptions{})
+		if rollbackErr != nil {
+			return newID, fmt.Errorf("printer: rotate: new cert (id: %s) not served (%s) and rollback to old cert (id: %s) failed (%s)", newID, lastResult, oldID, rollbackErr)
+		}
+
+		return newID, fmt.Errorf("%w (new id: %s, old id: %s) (%s) (%w)", ErrRotateRolledBack, newID, oldID, lastResult, err)
+	}
+
+	// clean up
+	if opts.DeleteOld && oldID != "0" && oldID != newID {
+		err = p.withSessionRetry(ctx, func(ctx context.Context) error {
+			return p.deleteCert(p.dryRunContext(ctx, "delete cert", oldID), oldID, DeleteCertOptions{})
+		})
+		if err != nil {
+			return newID, fmt.Errorf("printer: rotate: new cert (id: %s) active but failed to delete old cert (id: %s) (%w)", newID, oldID, err)
+		}
+	}
+
+	return newID, nil
+}
@@ -0,0 +1,225 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// not all models have this page
+const urlCertCreateSelfSigned = "/net/security/certificate/create.html"
+
+var errSelfSignedCommonNameRequired = errors.New("printer: self-signed cert: common name is required")
+
+// e.g. `Validity Period (days)`
+var regexLabelValidity = regexp.MustCompile(`(?i)valid|days|period|expir`)
+
+// SelfSignedParams are the values of the self-signed certificate the printer
+// generates. Empty fields are left as the printer's defaults.
+type SelfSignedParams struct {
+	CommonName string
+
+	// ValidityDays is how many days the cert is valid for
+	ValidityDays int
+
+	// KeyAlgo is the key type and size (see SupportedKeyAlgorithms)
+	KeyAlgo KeyAlgo
+}
+
+// parseValidityFieldName returns the name of the validity period field (a
+// text input or select) of the Create Self-Signed Certificate form
+func parseValidityFieldName(bodyBytes []byte) (string, bool) {
+	labels := parseLabels(bodyBytes)
+
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		switch strings.ToLower(attrs["type"]) {
+		case "", "text", "number":
+		default:
+			continue
+		}
+
+		if attrs["name"] != "" && regexLabelValidity.MatchString(fieldText(bodyBytes, input.start, attrs["id"], labels)) {
+			return attrs["name"], true
+		}
+	}
+
+	for _, sel := range parseElements(bodyBytes, "select") {
+		attrs := sel.attrs
+		if attrs["name"] != "" && regexLabelValidity.MatchString(fieldText(bodyBytes, sel.start, attrs["id"], labels)) {
+			return attrs["name"], true
+		}
+	}
+
+	return "", false
+}
+
+// CreateSelfSignedCert makes the printer generate a self-signed certificate
+// (and its key), using its Create Self-Signed Certificate page. It returns
+// the id value of the new cert. If the model can't create self-signed certs,
+// ErrUnsupported is returned.
+func (p *printer) CreateSelfSignedCert(params SelfSignedParams) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if params.CommonName == "" {
+		return "", errSelfSignedCommonNameRequired
+	}
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	id := ""
+	err := p.withSessionRetry(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = p.createSelfSignedCert(p.dryRunContext(ctx, "create self-signed cert", ""), params)
+		return err
+	})
+
+	return id, err
+}
+
+// createSelfSignedCert performs CreateSelfSignedCert using ctx
+func (p *printer) createSelfSignedCert(ctx context.Context, params SelfSignedParams) (string, error) {
+	// GET current cert IDs
+	origCertIDs, err := p.getCertIDs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// GET create page
+	bodyBytes, err := p.getOptionalPage(ctx, urlCertCreateSelfSigned)
+	if err != nil {
+		return "", err
+	}
+
+	p.logForm(ctx, urlCertCreateSelfSigned, bodyBytes)
+
+	// form values are the page's defaults with the params filled in
+	data := parseFormValues(bodyBytes)
+	if !hasCSRFToken(data) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return "", err
+		}
+		data.Set(csrfToken.name, csrfToken.value)
+	}
+
+	commonNameFields := parseCSRFieldNames(bodyBytes)[csrFieldCommonName]
+	if len(commonNameFields) == 0 {
+		return "", errors.New("printer: self-signed cert: common name field not found on page")
+	}
+	data.Set(commonNameFields[0], params.CommonName)
+
+	if params.ValidityDays > 0 {
+		name, ok := parseValidityFieldName(bodyBytes)
+		if !ok {
+			return "", fmt.Errorf("%w (model doesn't support setting self-signed cert validity)", ErrUnsupported)
+		}
+		data.Set(name, strconv.Itoa(params.ValidityDays))
+	}
+
+	if params.KeyAlgo != (KeyAlgo{}) {
+		name, value, err := parseKeyAlgoSelect(bodyBytes, params.KeyAlgo)
+		if err != nil {
+			return "", err
+		}
+		data.Set(name, value)
+	}
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return "", err
+	}
+	u.Path = p.urlPath(urlCertCreateSelfSigned)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		plan.planForm(u, data)
+		return "", plan
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK && !isRedirect(resp.StatusCode) {
+		return "", newHTTPStatusError("post of create self-signed cert form", resp)
+	}
+
+	// rejected?
+	err = checkBodyForImportFailed(bodyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	// generating the key takes a while; poll the cert list until the new
+	// cert appears
+	newCertIDs, err := p.awaitNewCertIDs(ctx, origCertIDs)
+	if err != nil {
+		return "", err
+	}
+
+	// identify the new cert (there is no fingerprint to match as the printer
+	// made the cert)
+	newId, err := diffNewCertID(origCertIDs, newCertIDs)
+	if err == nil && newId != "" {
+		return newId, nil
+	}
+
+	// more than one new cert; the one with the common name is ours
+	return p.newCertIDByCommonName(ctx, params.CommonName, origCertIDs)
+}
+
+// newCertIDByCommonName returns the ID of the only cert with the specified
+// Common Name that isn't in origCertIDs
+func (p *printer) newCertIDByCommonName(ctx context.Context, cn string, origCertIDs []string) (string, error) {
+	infos, err := p.listCerts(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	matchIDs := []string{}
+	for _, info := range infos {
+		if !slices.Contains(origCertIDs, info.ID) && strings.EqualFold(info.CommonName, cn) {
+			matchIDs = append(matchIDs, info.ID)
+		}
+	}
+
+	if len(matchIDs) != 1 {
+		return "", errors.New("printer: self-signed cert: failed to deduce new cert's id")
+	}
+
+	return matchIDs[0], nil
+}
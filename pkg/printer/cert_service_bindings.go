@@ -0,0 +1,204 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Service is a printer service that can be bound to a certificate
+type Service string
+
+const (
+	ServiceWebUI Service = "webui"
+	ServiceIPP   Service = "ipp"
+	ServiceFTP   Service = "ftp"
+	ServiceSMTP  Service = "smtp"
+	ServiceLDAP  Service = "ldap"
+	ServiceDot1x Service = "dot1x"
+)
+
+// serviceCertPages are the settings pages of services that have their own cert
+// select (WebUI and IPP are on the http settings page instead). not all models
+// have all of these pages.
+//
+// NOTE: these paths follow the naming of the http settings page and haven't
+// been confirmed against a printer that has the pages. a page that doesn't
+// exist returns ErrUnsupported, and a page without a select labeled as a
+// certificate is never submitted, so a wrong path can't change a setting.
+var serviceCertPages = map[Service]string{
+	ServiceFTP:  "/net/net/certificate/ftp.html",
+	ServiceSMTP: "/net/net/certificate/smtp.html",
+	ServiceLDAP: "/net/net/certificate/ldap.html",
+	// wired 802.1X client (identity) cert
+	ServiceDot1x: "/net/wired/wired_8021x.html",
+}
+
+// serviceHttpsLabels are the labels of the http settings page https checkboxes
+// for the services on that page
+var serviceHttpsLabels = map[Service]string{
+	ServiceWebUI: "Web Based Management",
+	ServiceIPP:   "IPP",
+}
+
+// getOptionalPage fetches a page which may not exist on all models.
+// If it doesn't exist, ErrUnsupported is returned.
+func (p *printer) getOptionalPage(ctx context.Context, path string) ([]byte, error) {
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = p.urlPath(path)
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// page doesn't exist on this model?
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w (page %s)", ErrUnsupported, path)
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPStatusError(fmt.Sprintf("get of page %s", path), resp)
+	}
+
+	return bodyBytes, nil
+}
+
+// e.g. `Client Certificate`
+var regexLabelCertificate = regexp.MustCompile(`(?i)certificate`)
+
+// parseCertSelectName returns the name of the cert select of a settings page,
+// which is the dropdown labeled as a certificate (pages such as 802.1X also
+// have dropdowns for other settings). if no dropdown is labeled as a
+// certificate, "" is returned.
+func parseCertSelectName(bodyBytes []byte) string {
+	labels := parseLabels(bodyBytes)
+
+	for _, sel := range parseElements(bodyBytes, "select") {
+		attrs := sel.attrs
+		if attrs["name"] == "" {
+			continue
+		}
+
+		if regexLabelCertificate.MatchString(precedingLabel(bodyBytes, sel.start, attrs["id"], labels)) {
+			return attrs["name"]
+		}
+	}
+
+	return ""
+}
+
+// setCertSelectOnPage changes the cert select of the settings page at path to
+// the specified cert ID and submits the page. all other settings on the page
+// are resubmitted unchanged.
+func (p *printer) setCertSelectOnPage(ctx context.Context, path string, id string) error {
+	form, err := p.getCertSelectForm(ctx, path, id)
+	if err != nil {
+		return err
+	}
+
+	return p.postCertSelectForm(ctx, form)
+}
+
+// certSelectForm is a settings page's form with its cert select changed,
+// ready to be submitted
+type certSelectForm struct {
+	path string
+	data url.Values
+}
+
+// getCertSelectForm gets the settings page at path and returns its form with
+// the cert select changed to the specified cert ID. nothing is submitted.
+func (p *printer) getCertSelectForm(ctx context.Context, path string, id string) (certSelectForm, error) {
+	// GET settings page
+	bodyBytes, err := p.getOptionalPage(ctx, path)
+	if err != nil {
+		return certSelectForm{}, err
+	}
+
+	selectField := parseCertSelectName(bodyBytes)
+	if selectField == "" {
+		return certSelectForm{}, fmt.Errorf("%w (no cert select on page %s)", ErrUnsupported, path)
+	}
+
+	// form values are the page's current values with the cert changed
+	data := parseFormValues(bodyBytes)
+	if !hasCSRFToken(data) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return certSelectForm{}, err
+		}
+		data.Set(csrfToken.name, csrfToken.value)
+	}
+	data.Set(selectField, id)
+
+	return certSelectForm{path: path, data: data}, nil
+}
+
+// postCertSelectForm submits a form from getCertSelectForm
+func (p *printer) postCertSelectForm(ctx context.Context, form certSelectForm) error {
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return err
+	}
+	u.Path = p.urlPath(form.path)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		plan.planForm(u, form.data)
+		return nil
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(form.data.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// read and discard entire body
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError(fmt.Sprintf("post of settings page %s", form.path), resp)
+	}
+
+	return nil
+}
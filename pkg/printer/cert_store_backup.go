@@ -0,0 +1,245 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// these pages only exist on some higher-end models
+const (
+	urlCertStoreBackup  = "/net/security/certificate/backup.html"
+	urlCertStoreRestore = "/net/security/certificate/restore.html"
+)
+
+// ErrUnsupported is returned when the printer does not offer the
+// requested feature
+var ErrUnsupported = errors.New("printer: feature not supported by this printer")
+
+// parsePasswordFieldNames returns the name attribute of every password input
+// field in the html response input (e.g. a password and its confirmation)
+func parsePasswordFieldNames(bodyBytes []byte) []string {
+	names := []string{}
+
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if strings.EqualFold(attrs["type"], "password") && attrs["name"] != "" {
+			names = append(names, attrs["name"])
+		}
+	}
+
+	return names
+}
+
+// BackupCertStore exports the printer's entire certificate store as an
+// encrypted blob, protected with the specified password. Only some models
+// support this; others return ErrUnsupported.
+func (p *printer) BackupCertStore(password string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if password == "" {
+		return nil, errors.New("printer: backup: password must be specified")
+	}
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	// GET backup page
+	bodyBytes, err := p.getOptionalPage(ctx, urlCertStoreBackup)
+	if err != nil {
+		return nil, err
+	}
+
+	// page must have somewhere to enter the password
+	passwordFields := parsePasswordFieldNames(bodyBytes)
+	if len(passwordFields) == 0 {
+		return nil, fmt.Errorf("%w (cert store backup form not found)", ErrUnsupported)
+	}
+
+	// form values (hidden fields include pageid and CSRFToken)
+	data := parseHiddenFormFields(bodyBytes)
+	if !hasCSRFToken(data) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return nil, err
+		}
+		data.Set(csrfToken.name, csrfToken.value)
+	}
+	for _, field := range passwordFields {
+		data.Set(field, password)
+	}
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = p.urlPath(urlCertStoreBackup)
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response (the blob)
+	blob, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPStatusError("post of cert store backup", resp)
+	}
+
+	// an html response means the printer showed a page instead of sending the file
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/html" {
+		// printer in maintenance mode?
+		err = checkBodyForMaintenance(blob)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if mediaType == "text/html" || len(blob) == 0 {
+		return nil, errors.New("printer: backup: printer did not return a cert store file")
+	}
+
+	return blob, nil
+}
+
+// RestoreCertStore imports a blob previously created by BackupCertStore,
+// replacing the printer's certificate store. Only some models support this;
+// others return ErrUnsupported.
+func (p *printer) RestoreCertStore(blob []byte, password string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(blob) == 0 {
+		return errors.New("printer: restore: cert store file is empty")
+	}
+	if password == "" {
+		return errors.New("printer: restore: password must be specified")
+	}
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+	ctx = p.dryRunContext(ctx, "restore cert store", "")
+
+	// GET restore page
+	bodyBytes, err := p.getOptionalPage(ctx, urlCertStoreRestore)
+	if err != nil {
+		return err
+	}
+
+	// page must have a file upload field
+	fileField, err := parseFileFieldName(bodyBytes)
+	if err != nil {
+		return fmt.Errorf("%w (cert store restore form not found)", ErrUnsupported)
+	}
+
+	// hidden fields include pageid and CSRFToken
+	hiddenFields := parseHiddenFormFields(bodyBytes)
+	if !hasCSRFToken(hiddenFields) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return err
+		}
+		hiddenFields.Set(csrfToken.name, csrfToken.value)
+	}
+
+	// make writer for multipart/form-data submission
+	var formDataBuffer bytes.Buffer
+	formWriter := multipart.NewWriter(&formDataBuffer)
+
+	// make form fields (in a stable order)
+	for _, name := range slices.Sorted(maps.Keys(hiddenFields)) {
+		for _, val := range hiddenFields[name] {
+			err = formWriter.WriteField(name, val)
+			if err != nil {
+				return fmt.Errorf("printer: restore: failed to write form (%w)", err)
+			}
+		}
+	}
+
+	passwordFields := parsePasswordFieldNames(bodyBytes)
+	for _, field := range passwordFields {
+		err = formWriter.WriteField(field, password)
+		if err != nil {
+			return fmt.Errorf("printer: restore: failed to write form (%w)", err)
+		}
+	}
+
+	fileW, err := formWriter.CreateFormFile(fileField, "certstore.bin")
+	if err != nil {
+		return fmt.Errorf("printer: restore: failed to write form (%w)", err)
+	}
+
+	_, err = io.Copy(fileW, bytes.NewReader(blob))
+	if err != nil {
+		return fmt.Errorf("printer: restore: failed to write form (%w)", err)
+	}
+
+	err = formWriter.Close()
+	if err != nil {
+		return fmt.Errorf("printer: restore: failed to close form (%w)", err)
+	}
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return err
+	}
+	u.Path = p.urlPath(urlCertStoreRestore)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		err = plan.planMultipartForm(u, formWriter.FormDataContentType(), formDataBuffer.Bytes(), passwordFields...)
+		if err != nil {
+			return err
+		}
+		return plan
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &formDataBuffer)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", formWriter.FormDataContentType())
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// read and discard entire body
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError("post of cert store restore", resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package printer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRestoreCertStoreEmptyPassword(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = p.RestoreCertStore([]byte("blob"), "")
+	if err == nil {
+		t.Fatal("RestoreCertStore() with empty password error = nil")
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("RestoreCertStore() made %d requests, want 0", got)
+	}
+}
@@ -0,0 +1,64 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// e.g. `Registered Certificates: 3/5` or `Used 3 of 5`
+var regexStoreUsage = regexp.MustCompile(`(?i)(?:used|registered|stored|installed)[^0-9]{0,40}?(\d+)\s*(?:/|of)\s*(\d+)`)
+
+// StoreUsage is how much of the printer's certificate store is in use
+type StoreUsage struct {
+	Used     int
+	Capacity int
+}
+
+// parseStoreUsage parses the cert store usage from the certificate list page.
+// If the page doesn't show it, ErrUnsupported is returned.
+func parseStoreUsage(bodyBytes []byte) (StoreUsage, error) {
+	caps := regexStoreUsage.FindStringSubmatch(htmlToText(bodyBytes))
+	if len(caps) != 3 {
+		return StoreUsage{}, fmt.Errorf("%w (cert store usage not shown)", ErrUnsupported)
+	}
+
+	used, err := strconv.Atoi(caps[1])
+	if err != nil {
+		return StoreUsage{}, fmt.Errorf("printer: failed to parse cert store usage (%w)", err)
+	}
+
+	capacity, err := strconv.Atoi(caps[2])
+	if err != nil {
+		return StoreUsage{}, fmt.Errorf("printer: failed to parse cert store usage (%w)", err)
+	}
+
+	return StoreUsage{
+		Used:     used,
+		Capacity: capacity,
+	}, nil
+}
+
+// getStoreUsage fetches the cert store usage
+func (p *printer) getStoreUsage(ctx context.Context) (StoreUsage, error) {
+	bodyBytes, err := p.getCertListPage(ctx, "")
+	if err != nil {
+		return StoreUsage{}, err
+	}
+
+	return parseStoreUsage(bodyBytes)
+}
+
+// GetStoreUsage returns how many certificates are in the printer's cert store
+// and how many it can hold. If the model doesn't show this, ErrUnsupported is
+// returned.
+func (p *printer) GetStoreUsage() (StoreUsage, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.getStoreUsage(ctx)
+}
@@ -2,117 +2,357 @@ package printer
 
 import (
 	"bytes"
+	"context"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
 )
 
 const urlCertImport = "/net/security/certificate/import.html"
 
-// UploadNewCert converts the specified pem files into p12 format and installs them
-// on the printer. It returns the id value of the newly installed cert.
-func (p *printer) UploadNewCert(keyPem, certPem []byte) (string, error) {
-	// make p12 from key and cert pem
-	p12, err := makeModernPfx(keyPem, certPem, "")
-	if err != nil {
-		return "", fmt.Errorf("printer: failed to make p12 file (%w)", err)
-	}
+// UploadOptions modifies the behavior of UploadNewCertWithOptions
+type UploadOptions struct {
+	// SettleCheck applies if the new cert can't be identified by its
+	// fingerprint (i.e. the printer doesn't show fingerprints). It requires
+	// the number of certs on the printer to increase by exactly one after the
+	// upload, and the new cert is the one new entry. Otherwise the new cert is
+	// deduced by comparing the ID lists.
+	SettleCheck bool
+
+	// ExtraFormFields are additional fields (name: value) to include in the
+	// import form, for firmware that requires inputs the form parsing doesn't
+	// cover. They are added after the standard fields, sorted by name.
+	ExtraFormFields map[string]string
+
+	// P12Password encrypts the p12 file with the password (and submits it
+	// with the import form). Some firmware rejects p12 files without a
+	// password. It is not used by models that import pem files.
+	P12Password string
+
+	// Intermediates are the intermediate CA certs (pem) to upload with the
+	// cert, so the printer presents the full chain. They must be in order
+	// (each signed the cert before it, starting with the leaf) and replace
+	// any chain in certPem. See MakePKCS12WithChain.
+	Intermediates [][]byte
+
+	// ExpiryWarning (if set) is called with the cert's expiration if it
+	// expires within ExpiryWarningWithin. Certs that have already expired are
+	// refused with ErrCertExpired regardless.
+	ExpiryWarningWithin time.Duration
+	ExpiryWarning       func(notAfter time.Time)
+
+	// SkipIfPresent skips the upload if a cert with the same SHA-256
+	// fingerprint is already installed, and returns the existing cert's ID
+	// instead. This makes repeated uploads of the same cert (e.g. a rotation
+	// job that is run twice) safe.
+	SkipIfPresent bool
+
+	// FieldOrder overrides the order the import form's fields are written in
+	// (by name). By default they follow the order of the fields on the import
+	// page, as a browser submits them, which some older firmware requires.
+	// Fields that aren't listed stay after the field they normally follow.
+	FieldOrder []string
+
+	// MultipartBoundary sets a fixed multipart boundary (instead of a random
+	// one), e.g. for reproducible requests in tests. See
+	// multipart.Writer.SetBoundary for the allowed characters.
+	MultipartBoundary string
+}
 
-	// GET current cert IDs
-	origCertIDs, err := p.getCertIDs()
-	if err != nil {
-		return "", err
-	}
+// defaultImportPasswordField is the import form's p12 password field, if it
+// can't be found on the page
+const defaultImportPasswordField = "B821"
 
-	// GET import page to obtain CSRFToken
+// defaultImportButtonFields are the submit button fields of the p12 import
+// form, for pages that don't render them as hidden inputs
+var defaultImportButtonFields = []string{"B8ea", "B8f8"}
+
+// getImportPage fetches the certificate import page
+func (p *printer) getImportPage(ctx context.Context) ([]byte, error) {
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	u.Path = urlCertImport
+	u.Path = p.urlPath(urlCertImport)
 
 	// make and do request
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// read body of response
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// busy page instead of the form?
+	if len(parseFileInputs(bodyBytes)) == 0 {
+		err = checkBodyForDeviceBusy(bodyBytes)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("printer: get of certificate import page failed (status code %d)", resp.StatusCode)
+		return nil, newHTTPStatusError("get of certificate import page", resp)
 	}
 
-	// find CSRFToken
-	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+	return bodyBytes, nil
+}
+
+// UploadNewCert converts the specified pem files into p12 format and installs them
+// on the printer. Models whose import page takes separate pem files instead of a
+// p12 are sent the pem files as-is. It returns the id value of the newly installed
+// cert.
+func (p *printer) UploadNewCert(keyPem, certPem []byte) (string, error) {
+	return p.UploadNewCertContext(context.Background(), keyPem, certPem)
+}
+
+// UploadNewCertContext performs UploadNewCert using ctx. If ctx is canceled
+// (or its deadline passes) during the upload, ctx's error is returned.
+func (p *printer) UploadNewCertContext(ctx context.Context, keyPem, certPem []byte) (string, error) {
+	info, err := p.uploadNewCertInfo(ctx, keyPem, certPem)
 	if err != nil {
 		return "", err
 	}
 
-	// make writer for multipart/form-data submission
-	var formDataBuffer bytes.Buffer
-	formWriter := multipart.NewWriter(&formDataBuffer)
+	return info.ID, nil
+}
 
-	// make form fields
-	err = formWriter.WriteField("pageid", "390")
-	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+// UploadNewCertWithPassword performs UploadNewCert, protecting the p12 file
+// with the specified password
+func (p *printer) UploadNewCertWithPassword(keyPem, certPem []byte, p12Password string) (string, error) {
+	return p.UploadNewCertWithOptions(keyPem, certPem, UploadOptions{P12Password: p12Password})
+}
+
+// UploadNewCertWithChain performs UploadNewCert, including the specified
+// intermediate CA certs (see UploadOptions.Intermediates)
+func (p *printer) UploadNewCertWithChain(keyPem, certPem []byte, intermediates [][]byte) (string, error) {
+	return p.UploadNewCertWithOptions(keyPem, certPem, UploadOptions{Intermediates: intermediates})
+}
+
+// UploadNewCertWithOptions performs UploadNewCert using the specified options
+func (p *printer) UploadNewCertWithOptions(keyPem, certPem []byte, opts UploadOptions) (string, error) {
+	return p.uploadNewCertContext(context.Background(), keyPem, certPem, nil, opts)
+}
+
+// uploadNewCertContext performs uploadNewCert bounded by ctx and the
+// operation budget, and reports cancellation as ctx's error
+func (p *printer) uploadNewCertContext(ctx context.Context, keyPem, certPem, p12 []byte, opts UploadOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+
+	id, err := p.uploadNewCertWithRetries(ctx, keyPem, certPem, p12, opts)
+	if err != nil && ctx.Err() != nil {
+		return "", fmt.Errorf("printer: upload: %w", ctx.Err())
 	}
 
-	err = formWriter.WriteField("CSRFToken", csrfToken)
-	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+	return id, err
+}
+
+// uploadNewCertWithRetries performs uploadNewCert, starting over if the
+// session expires before the POST is accepted or (if allowed by WithRetry)
+// the POST fails and the cert doesn't appear within the poll timeout. If it
+// does appear, it is identified as if the POST had succeeded.
+func (p *printer) uploadNewCertWithRetries(ctx context.Context, keyPem, certPem, p12 []byte, opts UploadOptions) (string, error) {
+	id := ""
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = p.withSessionRetry(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = p.uploadNewCert(p.dryRunContext(ctx, "upload cert", ""), keyPem, certPem, p12, opts)
+			return err
+		})
+
+		// only start over if the POST failed and didn't install the cert
+		var postErr *importPostError
+		if attempt >= p.uploadRetries || !errors.As(err, &postErr) || !isTransientError(postErr.err) {
+			break
+		}
+
+		sleepErr := sleepContext(ctx, p.transport.retryWait(attempt))
+		if sleepErr != nil {
+			break
+		}
+
+		// the printer may have received the POST and still be installing the
+		// cert (which takes several seconds), so wait for it before posting
+		// again
+		resumeCtx := withoutSessionCheck(ctx)
+		newCertIDs, awaitErr := p.awaitNewCertIDs(resumeCtx, postErr.origCertIDs)
+		if awaitErr == nil {
+			return p.identifyNewCert(resumeCtx, certPem, postErr.origCertIDs, newCertIDs, opts)
+		}
+
+		// unless it surely wasn't installed, don't risk installing it twice
+		if !errors.Is(awaitErr, ErrUploadNoNewCert) {
+			break
+		}
 	}
 
-	err = formWriter.WriteField("B8ea", "")
-	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+	return id, err
+}
+
+// uploadNewCert performs UploadNewCertWithOptions using ctx. If p12 isn't nil,
+// it is uploaded as-is (instead of a p12 made from keyPem) and certPem is only
+// used to check and identify the cert.
+func (p *printer) uploadNewCert(ctx context.Context, keyPem, certPem, p12 []byte, opts UploadOptions) (string, error) {
+	// refuse mismatched or expired key/cert before contacting the printer
+	if p12 == nil {
+		err := checkKeyCertPair(keyPem, certPem, opts.ExpiryWarningWithin, opts.ExpiryWarning)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	err = formWriter.WriteField("B8f8", "")
+	// refuse certs the printer would reject
+	err := p.checkCertValidity(certPem)
 	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		return "", err
 	}
 
-	err = formWriter.WriteField("hidden_certificate_process_control", "1")
-	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+	// already installed?
+	if opts.SkipIfPresent {
+		cert, _, err := certPemToCerts(certPem)
+		if err != nil {
+			return "", err
+		}
+
+		existingID, present, err := p.presentCertID(ctx, cert)
+		if err != nil {
+			return "", err
+		}
+		if present {
+			return existingID, nil
+		}
 	}
 
-	p12W, err := formWriter.CreateFormFile("B820", "certkey.p12")
+	// GET current cert IDs
+	origCertIDs, err := p.getCertIDs(ctx)
 	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		return "", err
 	}
 
-	_, err = io.Copy(p12W, bytes.NewReader(p12))
+	// GET import page to obtain CSRFToken
+	p.progress(ProgressFetchingImportPage, 10)
+	bodyBytes, err := p.getImportPage(ctx)
 	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		return "", err
 	}
 
-	err = formWriter.WriteField("B821", "")
+	p.logForm(ctx, urlCertImport, bodyBytes)
+
+	// find CSRFToken
+	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
 	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		return "", err
 	}
 
-	err = formWriter.WriteField("hidden_cert_import_password", "")
+	// make writer for multipart/form-data submission
+	var formDataBuffer bytes.Buffer
+	formWriter := multipart.NewWriter(&formDataBuffer)
+	if opts.MultipartBoundary != "" {
+		err = formWriter.SetBoundary(opts.MultipartBoundary)
+		if err != nil {
+			return "", fmt.Errorf("printer: upload: invalid multipart boundary (%w)", err)
+		}
+	}
+
+	// write fields in the caller's order, else the page's
+	fieldOrder := opts.FieldOrder
+	if fieldOrder == nil {
+		fieldOrder = parseFormFieldOrder(bodyBytes)
+	}
+
+	// some models take separate pem cert and key files instead of a p12
+	format, err := parseImportFormat(bodyBytes)
 	if err != nil {
-		return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		return "", err
+	}
+
+	if format == ImportFormatPem {
+		if p12 != nil {
+			return "", fmt.Errorf("%w (model's import page takes pem files, not a p12)", ErrUnsupported)
+		}
+
+		// leaf and caller's chain (if any)
+		chainPem := certPem
+		if opts.Intermediates != nil {
+			cert, _, err := certPemToCerts(certPem)
+			if err != nil {
+				return "", err
+			}
+			_, err = intermediatesToCerts(cert, opts.Intermediates)
+			if err != nil {
+				return "", err
+			}
+
+			chainPem = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+			for _, intermediatePem := range opts.Intermediates {
+				chainPem = appendPem(chainPem, intermediatePem)
+			}
+		}
+
+		err = writeImportFormPem(formWriter, bodyBytes, parseFileInputs(bodyBytes), keyPem, chainPem, fieldOrder)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// make p12 from key and cert pem (unless provided)
+		if p12 == nil {
+			p12, err = MakePKCS12WithChain(keyPem, certPem, opts.Intermediates, opts.P12Password)
+			if err != nil {
+				return "", fmt.Errorf("printer: failed to make p12 file (%w)", err)
+			}
+		}
+
+		passwordField, err := parsePasswordFieldName(bodyBytes)
+		if err != nil {
+			passwordField = defaultImportPasswordField
+		}
+		p.logger.DebugContext(ctx, "printer: writing p12 import form", slog.String("password_field", passwordField), slog.Int("p12_len", len(p12)))
+
+		buttonFields := p.importButtonFields(ctx, bodyBytes)
+		err = writeImportFormPfx(formWriter, csrfToken, buttonFields, p12, passwordField, opts.P12Password, fieldOrder)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// caller's extra fields
+	extraNames := slices.Sorted(maps.Keys(opts.ExtraFormFields))
+	for _, name := range extraNames {
+		err = formWriter.WriteField(name, opts.ExtraFormFields[name])
+		if err != nil {
+			return "", fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		}
 	}
 
 	err = formWriter.Close()
@@ -121,47 +361,152 @@ func (p *printer) UploadNewCert(keyPem, certPem []byte) (string, error) {
 	}
 
 	// get url & set path
-	u, err = url.ParseRequestURI(p.baseUrl)
+	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return "", err
 	}
-	u.Path = urlCertImport
+	u.Path = p.urlPath(urlCertImport)
+
+	// dry run? (without the import page's password fields)
+	if plan := dryRunPlan(ctx); plan != nil {
+		secretFields := append(parsePasswordFieldNames(bodyBytes), defaultImportPasswordField)
+		err = plan.planMultipartForm(u, formWriter.FormDataContentType(), formDataBuffer.Bytes(), secretFields...)
+		if err != nil {
+			return "", fmt.Errorf("printer: upload: failed to plan form (%w)", err)
+		}
+		return "", plan
+	}
 
 	// make and do request
-	req, err = http.NewRequest(http.MethodPost, u.String(), &formDataBuffer)
+	p.progress(ProgressUploading, 30)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &formDataBuffer)
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Content-Type", formWriter.FormDataContentType())
 
-	resp, err = p.httpClient.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", &importPostError{err: err, origCertIDs: origCertIDs}
 	}
 	defer resp.Body.Close()
 
+	// the POST was accepted; from here on a lost session is logged in again
+	// and the upload resumes (starting over would install the cert twice)
+	ctx = withoutSessionCheck(ctx)
+
 	// read body of response
-	_, _ = io.Copy(io.Discard, resp.Body)
+	bodyBytes, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &importPostError{err: err, origCertIDs: origCertIDs}
+	}
 
-	// OK status?
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("printer: post of new certificate failed (status code %d)", resp.StatusCode)
+	// some firmware redirects to a result page instead of responding with it
+	if isRedirect(resp.StatusCode) {
+		bodyBytes, err = p.getImportResultPage(ctx, resp)
+		if err != nil {
+			return "", err
+		}
+	} else if resp.StatusCode != http.StatusOK {
+		return "", &importPostError{err: newHTTPStatusError("post of new certificate", resp), origCertIDs: origCertIDs}
 	}
 
-	// normally the webUI would show a waiting screen for ~7 seconds. insert
-	// a delay here to account for any processing the device might do
-	// before next steps
-	time.Sleep(10 * time.Second)
+	// rejected as already installed?
+	err = p.checkBodyForDuplicateCert(ctx, bodyBytes, certPem)
+	if err != nil {
+		return "", err
+	}
+
+	// rejected for some other reason?
+	err = checkBodyForImportFailed(bodyBytes)
+	if err != nil {
+		return "", err
+	}
 
-	// get new cert ID list
-	newCertIDs, err := p.getCertIDs()
+	// normally the webUI would show a waiting screen for ~7 seconds. poll
+	// the cert list until the new cert appears
+	p.progress(ProgressWaitingForDevice, 60)
+	newCertIDs, err := p.awaitNewCertIDs(ctx, origCertIDs)
 	if err != nil {
 		return "", err
 	}
 
-	// find ID that is in new list but not in old (this is the new one)
-	newId := ""
-	countNew := 0
+	return p.identifyNewCert(ctx, certPem, origCertIDs, newCertIDs, opts)
+}
+
+// identifyNewCert returns the ID of the uploaded cert, given the cert IDs
+// from before the upload and after it appeared
+func (p *printer) identifyNewCert(ctx context.Context, certPem []byte, origCertIDs, newCertIDs []string, opts UploadOptions) (string, error) {
+	// identify the new cert (by fingerprint if the printer shows it)
+	p.progress(ProgressVerifying, 90)
+	newId, ok := p.newCertIDByFingerprint(ctx, certPem, origCertIDs, newCertIDs)
+	if ok {
+		p.progress(ProgressDone, 100)
+		return newId, nil
+	}
+
+	var err error
+	if opts.SettleCheck {
+		newId, err = settledNewCertID(origCertIDs, newCertIDs)
+	} else {
+		newId, err = diffNewCertID(origCertIDs, newCertIDs)
+	}
+	if err != nil {
+		return "", err
+	}
+	if newId == "" {
+		return "", ErrUploadNoNewCert
+	}
+
+	p.progress(ProgressDone, 100)
+	return newId, nil
+}
+
+// awaitNewCertIDs polls the cert ID list until it contains a cert that isn't
+// in origCertIDs and returns it. errors getting the list are tolerated (the
+// device may be busy processing the upload) until the poll timeout.
+func (p *printer) awaitNewCertIDs(ctx context.Context, origCertIDs []string) ([]string, error) {
+	return p.awaitNewIDs(ctx, origCertIDs, p.getCertIDs)
+}
+
+// awaitNewIDs performs awaitNewCertIDs using getIDs to get the ID list (e.g.
+// of a different cert store)
+func (p *printer) awaitNewIDs(ctx context.Context, origCertIDs []string, getIDs func(ctx context.Context) ([]string, error)) ([]string, error) {
+	deadline := time.Now().Add(p.pollTimeout)
+
+	for {
+		err := sleepContext(ctx, p.pollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("printer: upload: %w", err)
+		}
+
+		// session may have expired while waiting
+		err = p.refreshSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		newCertIDs, err := getIDs(ctx)
+		if err == nil {
+			newId, diffErr := diffNewCertID(origCertIDs, newCertIDs)
+			if newId != "" || diffErr != nil || len(newCertIDs) > len(origCertIDs) {
+				return newCertIDs, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w (timed out waiting for it)", ErrUploadNoNewCert)
+		}
+	}
+}
+
+// diffNewCertID returns the ID that is in the new ID list but not in the
+// original (which is the newly uploaded cert)
+func diffNewCertID(origCertIDs, newCertIDs []string) (string, error) {
+	newIds := []string{}
 	for i := range newCertIDs {
 		found := false
 
@@ -174,15 +519,101 @@ func (p *printer) UploadNewCert(keyPem, certPem []byte) (string, error) {
 		}
 
 		if !found {
-			newId = newCertIDs[i]
-			countNew++
+			newIds = append(newIds, newCertIDs[i])
+		}
+	}
+
+	switch len(newIds) {
+	case 0:
+		return "", nil
+	case 1:
+		return newIds[0], nil
+	default:
+		// if more than one new, can't determine which was uploaded by this app
+		return "", &UploadAmbiguousError{CandidateIDs: newIds}
+	}
+}
+
+// settledNewCertID returns the ID of the newly uploaded cert, after confirming
+// the number of certs increased by exactly one
+func settledNewCertID(origCertIDs, newCertIDs []string) (string, error) {
+	if len(newCertIDs) != len(origCertIDs)+1 {
+		if len(newCertIDs) <= len(origCertIDs) {
+			return "", fmt.Errorf("%w (cert count was %d, now %d)", ErrUploadNoNewCert, len(origCertIDs), len(newCertIDs))
+		}
+
+		candidateIDs := []string{}
+		for _, id := range newCertIDs {
+			if !slices.Contains(origCertIDs, id) {
+				candidateIDs = append(candidateIDs, id)
+			}
 		}
+		return "", &UploadAmbiguousError{CandidateIDs: candidateIDs}
 	}
 
-	// if more than one new, can't determine which was uploaded by this app
-	if countNew > 1 {
-		return "", errors.New("printer: upload: failed to deduce new cert's id")
+	// the new entry
+	newId, err := diffNewCertID(origCertIDs, newCertIDs)
+	if err != nil || newId == "" {
+		// an ID was reused; the printer lists certs in the order installed
+		return newCertIDs[len(newCertIDs)-1], nil
 	}
 
 	return newId, nil
 }
+
+// importButtonFields returns the dynamic hidden fields (e.g. `B8ea` and
+// `B8f8`) of the p12 import page, in page order. Models usually render two,
+// but some render only one, in which case only it is sent (a blank second
+// field breaks the form). If the page has none, defaultImportButtonFields
+// are used.
+func (p *printer) importButtonFields(ctx context.Context, bodyBytes []byte) []string {
+	fields := parseDynamicHiddenFields(bodyBytes)
+	p.logger.DebugContext(ctx, "printer: found import page dynamic hidden fields", slog.Int("count", len(fields)), slog.Any("fields", fields))
+
+	// not rendered as hidden inputs; use the known fields
+	if len(fields) == 0 {
+		return defaultImportButtonFields
+	}
+
+	// as many as the page has (even if only one)
+	return fields
+}
+
+// writeImportFormPfx writes the fields of the (standard) import form which
+// takes a single p12 file and its password. buttonFields are the model's
+// submit button fields. The fields are written in order (see orderFormParts).
+func writeImportFormPfx(formWriter *multipart.Writer, csrfToken csrfToken, buttonFields []string, p12 []byte, passwordField, password string, order []string) error {
+	// make form fields
+	parts := []formPart{
+		{name: "pageid", value: "390"},
+		{name: csrfToken.name, value: csrfToken.value},
+	}
+	for _, field := range buttonFields {
+		parts = append(parts, formPart{name: field})
+	}
+	parts = append(parts,
+		formPart{name: "hidden_certificate_process_control", value: "1"},
+		formPart{name: "B820", fileName: "certkey.p12", content: p12},
+		formPart{name: passwordField, value: password},
+		formPart{name: "hidden_cert_import_password", value: password},
+	)
+
+	return writeFormParts(formWriter, orderFormParts(parts, order))
+}
+
+// importPostError is an error of the POST of the import form (after which
+// it is unknown whether the cert was installed)
+type importPostError struct {
+	err         error
+	origCertIDs []string
+}
+
+// Error implements error
+func (e *importPostError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *importPostError) Unwrap() error {
+	return e.err
+}
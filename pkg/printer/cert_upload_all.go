@@ -0,0 +1,56 @@
+package printer
+
+import (
+	"context"
+	"sync"
+)
+
+// UploadResult is the result of uploading a cert to one of the printers
+// passed to UploadToAll
+type UploadResult struct {
+	Printer *printer
+	// ID is the id value of the newly installed cert ("" if Err isn't nil)
+	ID  string
+	Err error
+}
+
+// UploadToAll performs UploadNewCert on each of the printers, at most
+// concurrency at a time, and returns the results in the same order as
+// printers. A failure on one printer doesn't stop the others.
+func UploadToAll(printers []*printer, keyPem, certPem []byte, concurrency int) []UploadResult {
+	return UploadToAllContext(context.Background(), printers, keyPem, certPem, concurrency)
+}
+
+// UploadToAllContext performs UploadToAll using ctx. If ctx is done, uploads
+// in progress are canceled and those not yet started fail with ctx's error.
+func UploadToAllContext(ctx context.Context, printers []*printer, keyPem, certPem []byte, concurrency int) []UploadResult {
+	concurrency = max(concurrency, 1)
+
+	results := make([]UploadResult, len(printers))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, p := range printers {
+		results[i].Printer = p
+
+		// wait for a free worker (or cancellation)
+		select {
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i].ID, results[i].Err = p.UploadNewCertContext(ctx, keyPem, certPem)
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,64 @@
+package printer
+
+import (
+	"encoding/pem"
+	"errors"
+)
+
+var (
+	errCombinedNoKey        = errors.New("printer: combined pem has no private key block")
+	errCombinedMultipleKeys = errors.New("printer: combined pem has more than one private key block")
+	errCombinedNoCert       = errors.New("printer: combined pem has no certificate block")
+)
+
+// splitCombinedPem splits a pem file containing both a private key and a
+// certificate (and optionally its chain) into the key pem and the cert pem.
+// Certificate blocks stay in their original order, so the first one must be
+// the leaf. Other block types are ignored.
+func splitCombinedPem(combinedPem []byte) (keyPem, certPem []byte, err error) {
+	rest := combinedPem
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY":
+			if keyPem != nil {
+				return nil, nil, errCombinedMultipleKeys
+			}
+			keyPem = pem.EncodeToMemory(block)
+
+		case "CERTIFICATE":
+			certPem = appendPem(certPem, pem.EncodeToMemory(block))
+
+		default:
+			// e.g. `EC PARAMETERS`
+		}
+	}
+
+	if keyPem == nil {
+		return nil, nil, errCombinedNoKey
+	}
+	if certPem == nil {
+		return nil, nil, errCombinedNoCert
+	}
+
+	return keyPem, certPem, nil
+}
+
+// UploadNewCertCombined performs UploadNewCert using a single pem file that
+// contains both the private key and the certificate (in either order), as
+// some tools (e.g. ACME clients) write them. If there is more than one
+// certificate, the first is the leaf and the rest are its chain. An error is
+// returned if there isn't exactly one private key or there is no certificate.
+func (p *printer) UploadNewCertCombined(combinedPem []byte) (string, error) {
+	keyPem, certPem, err := splitCombinedPem(combinedPem)
+	if err != nil {
+		return "", err
+	}
+
+	return p.UploadNewCert(keyPem, certPem)
+}
@@ -0,0 +1,56 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrDuplicateCert is returned when the printer rejects an uploaded cert
+// because it is already installed
+var ErrDuplicateCert = errors.New("printer: upload: cert is already installed on the printer")
+
+// e.g. `This certificate has already been registered.`
+var regexDuplicateCert = regexp.MustCompile(`(?i)already\s+(?:been\s+)?(?:installed|registered|imported|exists)|duplicate\s+certificate`)
+
+// DuplicateCertError is returned when the printer rejects an uploaded cert
+// because it is already installed. It matches ErrDuplicateCert with errors.Is.
+type DuplicateCertError struct {
+	// ExistingID is the ID of the already installed cert, if it could be found
+	ExistingID string
+}
+
+// Error implements error
+func (e *DuplicateCertError) Error() string {
+	if e.ExistingID == "" {
+		return ErrDuplicateCert.Error()
+	}
+
+	return fmt.Sprintf("%s (existing id: %s)", ErrDuplicateCert, e.ExistingID)
+}
+
+// Unwrap returns ErrDuplicateCert
+func (e *DuplicateCertError) Unwrap() error {
+	return ErrDuplicateCert
+}
+
+// checkBodyForDuplicateCert returns a DuplicateCertError if the import
+// response input shows the cert was rejected as a duplicate. The existing
+// cert's ID is found by matching the serial of certPem.
+func (p *printer) checkBodyForDuplicateCert(ctx context.Context, bodyBytes []byte, certPem []byte) error {
+	// match visible text only (not e.g. messages in the page's scripts)
+	if !regexDuplicateCert.MatchString(htmlToText(bodyBytes)) {
+		return nil
+	}
+
+	dupErr := &DuplicateCertError{}
+
+	// try to find the existing cert's ID (but don't fail if it can't be found)
+	cert, _, err := certPemToCerts(certPem)
+	if err == nil {
+		dupErr.ExistingID, _ = p.findCertIDBySerial(ctx, cert.SerialNumber.Bytes())
+	}
+
+	return dupErr
+}
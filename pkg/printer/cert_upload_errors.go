@@ -0,0 +1,35 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUploadNoNewCert is returned when no new cert appears in the printer's
+	// cert list after an upload (i.e. the upload didn't install the cert)
+	ErrUploadNoNewCert = errors.New("printer: upload: no new cert appeared")
+
+	// ErrUploadAmbiguous is returned when more than one new cert appears in
+	// the printer's cert list after an upload (e.g. another client uploaded
+	// at the same time), so which is the uploaded cert can't be deduced
+	ErrUploadAmbiguous = errors.New("printer: upload: failed to deduce new cert's id (more than one new cert)")
+)
+
+// UploadAmbiguousError is returned when more than one new cert appears after
+// an upload. It matches ErrUploadAmbiguous with errors.Is.
+type UploadAmbiguousError struct {
+	// CandidateIDs are the IDs of the new certs
+	CandidateIDs []string
+}
+
+// Error implements error
+func (e *UploadAmbiguousError) Error() string {
+	return fmt.Sprintf("%s (ids: %s)", ErrUploadAmbiguous, strings.Join(e.CandidateIDs, ", "))
+}
+
+// Unwrap returns ErrUploadAmbiguous
+func (e *UploadAmbiguousError) Unwrap() error {
+	return ErrUploadAmbiguous
+}
@@ -0,0 +1,79 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"slices"
+)
+
+var (
+	// e.g. `<dt>SHA-256 Fingerprint</dt><dd>AB:CD:...</dd>`
+	regexCertViewDetail = regexp.MustCompile(`(?is)<dt[^>]*>(.*?)</dt>\s*<dd[^>]*>(.*?)</dd>`)
+	// e.g. `SHA-256 Fingerprint` or `Thumbprint (SHA256)`
+	regexLabelSHA256     = regexp.MustCompile(`(?i)sha-?\s*256`)
+	regexLabelThumbprint = regexp.MustCompile(`(?i)finger\s*print|thumb\s*print`)
+)
+
+// parseCertViewFingerprint returns the SHA-256 fingerprint shown on a cert's
+// view page, if the page shows it
+func parseCertViewFingerprint(bodyBytes []byte) ([]byte, bool) {
+	for _, detail := range regexCertViewDetail.FindAllSubmatch(bodyBytes, -1) {
+		label := htmlToText(detail[1])
+		if !regexLabelSHA256.MatchString(label) || !regexLabelThumbprint.MatchString(label) {
+			continue
+		}
+
+		fingerprint, err := parseFingerprint(htmlToText(detail[2]))
+		if err != nil {
+			continue
+		}
+
+		return fingerprint, true
+	}
+
+	return nil, false
+}
+
+// newCertIDByFingerprint identifies the uploaded cert by matching the SHA-256
+// fingerprint of certPem against the fingerprints shown on the view pages of
+// the certs that appeared after the upload (or all certs, if the printer
+// reused an ID). This is reliable even if other certs were added at the same
+// time. If the view pages don't show fingerprints (or none match), false is
+// returned and the caller should fall back to comparing the ID lists.
+func (p *printer) newCertIDByFingerprint(ctx context.Context, certPem []byte, origCertIDs, newCertIDs []string) (string, bool) {
+	cert, _, err := certPemToCerts(certPem)
+	if err != nil {
+		return "", false
+	}
+	expected := certFingerprint(cert)
+
+	candidates := []string{}
+	for _, id := range newCertIDs {
+		if !slices.Contains(origCertIDs, id) {
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = newCertIDs
+	}
+
+	for _, id := range candidates {
+		bodyBytes, err := p.getCertViewPage(ctx, id)
+		if err != nil {
+			continue
+		}
+
+		fingerprint, ok := parseCertViewFingerprint(bodyBytes)
+		if !ok {
+			// if the model doesn't show it for one cert, it won't for any
+			return "", false
+		}
+
+		if bytes.Equal(fingerprint, expected) {
+			return id, true
+		}
+	}
+
+	return "", false
+}
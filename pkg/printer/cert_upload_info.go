@@ -0,0 +1,61 @@
+package printer
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// certInfoFromCert returns the metadata of cert, as the printer lists it
+// under the specified id
+func certInfoFromCert(id string, cert *x509.Certificate) *CertInfo {
+	// e.g. `06:22:61:1a:...` (as shown on the cert's view page)
+	serialHex := hex.EncodeToString(cert.SerialNumber.Bytes())
+	serialParts := []string{}
+	for i := 0; i < len(serialHex); i += 2 {
+		serialParts = append(serialParts, serialHex[i:i+2])
+	}
+
+	// certs with only SANs are named by the first
+	displayName := cert.Subject.CommonName
+	if displayName == "" && len(cert.DNSNames) > 0 {
+		displayName = cert.DNSNames[0]
+	}
+
+	return &CertInfo{
+		ID:          id,
+		CommonName:  cert.Subject.CommonName,
+		Issuer:      cert.Issuer.CommonName,
+		NotBefore:   cert.NotBefore.UTC(),
+		NotAfter:    cert.NotAfter.UTC(),
+		Serial:      strings.Join(serialParts, ":"),
+		DisplayName: displayName,
+
+		// uploads always include the key
+		HasPrivateKey: true,
+	}
+}
+
+// UploadNewCertInfo performs UploadNewCert and returns the metadata of the
+// installed cert (which is taken from certPem, so no further requests are
+// made). Dates are in UTC.
+func (p *printer) UploadNewCertInfo(keyPem, certPem []byte) (*CertInfo, error) {
+	return p.uploadNewCertInfo(context.Background(), keyPem, certPem)
+}
+
+// uploadNewCertInfo performs UploadNewCertInfo using ctx
+func (p *printer) uploadNewCertInfo(ctx context.Context, keyPem, certPem []byte) (*CertInfo, error) {
+	id, err := p.uploadNewCertContext(ctx, keyPem, certPem, nil, UploadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	cert, _, err := certPemToCerts(certPem)
+	if err != nil {
+		return nil, fmt.Errorf("printer: upload: failed to parse installed cert (%w)", err)
+	}
+
+	return certInfoFromCert(id, cert), nil
+}
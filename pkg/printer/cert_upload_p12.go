@@ -1,62 +1,63 @@
 package printer
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 
 	"software.sslmate.com/src/go-pkcs12"
 )
 
 // helper funcs to create p12 from pem
 
-var errUnsupportedKey = errors.New("printer: error: only rsa keys are supported")
+var errUnsupportedKey = errors.New("printer: error: only rsa and ecdsa keys are supported")
 
-// keyPemToKey returns the private key from pemBytes
-func keyPemToKey(keyPem []byte) (key *rsa.PrivateKey, err error) {
-	// decode private key
-	keyPemBlock, _ := pem.Decode(keyPem)
-	if keyPemBlock == nil {
-		return nil, errors.New("printer: key pem block did not decode")
+// ErrUnsupportedCurve is returned for ecdsa keys on curves other than P-256
+// and P-384, which are the only curves Brother firmware offers (on its Create
+// CSR page) and accepts on import. Older models (e.g. the MFC-L2710DW) reject
+// ecdsa certs altogether; see SupportedKeyAlgorithms.
+var ErrUnsupportedCurve = errors.New("printer: error: only ecdsa curves P-256 and P-384 are supported")
+
+// checkKeyCurve returns ErrUnsupportedCurve if key's curve isn't one the
+// printer accepts
+func checkKeyCurve(key *ecdsa.PrivateKey) error {
+	if key.Curve != elliptic.P256() && key.Curve != elliptic.P384() {
+		return fmt.Errorf("%w (key uses %s)", ErrUnsupportedCurve, key.Curve.Params().Name)
 	}
 
-	// parsing depends on block type
-	switch keyPemBlock.Type {
-	case "RSA PRIVATE KEY": // PKCS1
-		var rsaKey *rsa.PrivateKey
-		rsaKey, err = x509.ParsePKCS1PrivateKey(keyPemBlock.Bytes)
-		if err != nil {
-			return nil, err
-		}
+	return nil
+}
+
+// keyPemToKey returns the private key from pemBytes
+func keyPemToKey(keyPem []byte) (key crypto.PrivateKey, err error) {
+	signer, err := parsePrivateKeyPem(keyPem)
+	if err != nil {
+		return nil, err
+	}
 
+	switch k := signer.(type) {
+	case *rsa.PrivateKey:
 		// basic sanity check
-		err = rsaKey.Validate()
+		err = k.Validate()
 		if err != nil {
 			return nil, err
 		}
 
-		return rsaKey, nil
+		return k, nil
 
-	case "PRIVATE KEY": // PKCS8
-		pkcs8K, err := x509.ParsePKCS8PrivateKey(keyPemBlock.Bytes)
+	case *ecdsa.PrivateKey:
+		err = checkKeyCurve(k)
 		if err != nil {
 			return nil, err
 		}
 
-		switch pkcs8Key := pkcs8K.(type) {
-		case *rsa.PrivateKey:
-			// basic sanity check
-			err = pkcs8Key.Validate()
-			if err != nil {
-				return nil, err
-			}
-
-			return pkcs8Key, nil
-
-		default:
-			// fallthrough
-		}
+		return k, nil
 
 	default:
 		// fallthrough
@@ -65,6 +66,44 @@ func keyPemToKey(keyPem []byte) (key *rsa.PrivateKey, err error) {
 	return nil, errUnsupportedKey
 }
 
+// ErrChainInvalid is returned when the intermediate certs don't form a chain
+// from the leaf cert
+var ErrChainInvalid = errors.New("printer: intermediate certs do not chain to leaf cert")
+
+// intermediatesToCerts parses the intermediate cert pems and verifies each
+// cert signed the one before it (starting with leaf)
+func intermediatesToCerts(leaf *x509.Certificate, intermediates [][]byte) ([]*x509.Certificate, error) {
+	chain := []*x509.Certificate{}
+	for i, intermediatePem := range intermediates {
+		rest := intermediatePem
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("printer: failed to parse intermediate cert %d (%w)", i, err)
+			}
+			chain = append(chain, cert)
+		}
+	}
+
+	// each cert must be signed by the next
+	child := leaf
+	for _, cert := range chain {
+		err := child.CheckSignatureFrom(cert)
+		if err != nil {
+			return nil, fmt.Errorf("%w ('%s' is not signed by '%s': %s)", ErrChainInvalid, child.Subject, cert.Subject, err)
+		}
+		child = cert
+	}
+
+	return chain, nil
+}
+
 // certPemToCerts returns the certificate from cert pem bytes. if the pem
 // bytes contain more than one certificate, the first is returned as the
 // certificate and the 2nd is returned as the only member of an array. The
@@ -100,8 +139,21 @@ func certPemToCerts(certPem []byte) (cert *x509.Certificate, certChain []*x509.C
 	return cert, []*x509.Certificate{cert2}, nil
 }
 
-// makeModernPfx returns the pkcs12 pfx data for the given key and cert pem
-func makeModernPfx(keyPem, certPem []byte, password string) (pfxData []byte, err error) {
+// MakePKCS12 returns the pkcs12 pfx data for the given key and cert pem,
+// encoded using the modern pkcs12 standard (as UploadNewCert uploads it). If
+// certPem contains a chain, only the first intermediate is included (more
+// than 2 certs are too big to fit on the printer). Only rsa and ecdsa (P-256
+// or P-384) keys are supported.
+func MakePKCS12(keyPem, certPem []byte, password string) (pfxData []byte, err error) {
+	return MakePKCS12WithChain(keyPem, certPem, nil, password)
+}
+
+// MakePKCS12WithChain performs MakePKCS12, including the specified
+// intermediate CA certs (pem, each may contain more than one cert) in the
+// bundle instead of any chain in certPem. The intermediates must be in order:
+// each must have signed the cert before it (starting with the leaf), or
+// ErrChainInvalid is returned.
+func MakePKCS12WithChain(keyPem, certPem []byte, intermediates [][]byte, password string) (pfxData []byte, err error) {
 	// get private key
 	key, err := keyPemToKey(keyPem)
 	if err != nil {
@@ -114,7 +166,18 @@ func makeModernPfx(keyPem, certPem []byte, password string) (pfxData []byte, err
 		return nil, err
 	}
 
+	// caller's chain
+	if intermediates != nil {
+		certChain, err = intermediatesToCerts(cert, intermediates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// encode using modern pkcs12 standard
+	// NOTE: the bags have no friendlyName; go-pkcs12 can't set one, and the
+	// printer doesn't show it anyway (the cert list's name is the cert's
+	// Common Name), so label certs by their CN instead
 	pfxData, err = pkcs12.Modern.Encode(key, cert, certChain, password)
 	if err != nil {
 		return nil, err
@@ -122,3 +185,22 @@ func makeModernPfx(keyPem, certPem []byte, password string) (pfxData []byte, err
 
 	return pfxData, nil
 }
+
+// UploadP12 installs an existing pkcs12 (.p12/.pfx) bundle on the printer
+// as-is, using password to open it (it is also sent to the printer). It
+// returns the id value of the newly installed cert. Models whose import page
+// takes separate pem files return ErrUnsupported.
+func (p *printer) UploadP12(p12 []byte, password string) (string, error) {
+	// the cert is needed to check it and identify it once installed
+	_, cert, caCerts, err := pkcs12.DecodeChain(p12, password)
+	if err != nil {
+		return "", fmt.Errorf("printer: failed to decode p12 file (%w)", err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	for _, caCert := range caCerts {
+		certPem = appendPem(certPem, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}))
+	}
+
+	return p.uploadNewCertContext(context.Background(), nil, certPem, p12, UploadOptions{P12Password: password})
+}
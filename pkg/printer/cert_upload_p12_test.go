@@ -0,0 +1,96 @@
+package printer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestMakePKCS12RoundTrip(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sec1 encoding of the P-384 key (as openssl ecparam writes it)
+	p384Der, err := x509.MarshalECPrivateKey(p384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Sec1 := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: p384Der})
+
+	tests := []struct {
+		name   string
+		key    crypto.Signer
+		keyPem []byte
+	}{
+		{name: "P-256", key: p256},
+		{name: "P-384", key: p384},
+		{name: "P-384 sec1", key: p384, keyPem: p384Sec1},
+		{name: "rsa-2048", key: rsa2048},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyPem, certPem := testKeyCert(t, tt.key, "printer.example.com")
+			if tt.keyPem != nil {
+				keyPem = tt.keyPem
+			}
+
+			pfx, err := MakePKCS12(keyPem, certPem, "secret")
+			if err != nil {
+				t.Fatalf("MakePKCS12() error = %v", err)
+			}
+
+			key, cert, _, err := pkcs12.DecodeChain(pfx, "secret")
+			if err != nil {
+				t.Fatalf("DecodeChain() error = %v", err)
+			}
+
+			wantCert, _, err := certPemToCerts(certPem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cert.Equal(wantCert) {
+				t.Error("decoded cert doesn't match")
+			}
+
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				t.Fatalf("decoded key is %T, not a crypto.Signer", key)
+			}
+			if !signer.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.key.Public()) {
+				t.Error("decoded key doesn't match")
+			}
+		})
+	}
+}
+
+func TestMakePKCS12UnsupportedCurve(t *testing.T) {
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPem, certPem := testKeyCert(t, p521, "printer.example.com")
+
+	_, err = MakePKCS12(keyPem, certPem, "secret")
+	if !errors.Is(err, ErrUnsupportedCurve) {
+		t.Errorf("MakePKCS12() error = %v, want %v", err, ErrUnsupportedCurve)
+	}
+}
@@ -0,0 +1,96 @@
+package printer
+
+import (
+	"encoding/pem"
+	"errors"
+	"maps"
+	"mime/multipart"
+	"regexp"
+	"slices"
+)
+
+// e.g. `Private Key` (label of the key file input on pem import pages)
+var regexLabelKey = regexp.MustCompile(`(?i)key`)
+
+// pemImportFileFields returns which of the import page's file inputs is for
+// the certificate and which is for the private key
+func pemImportFileFields(fileInputs []formFileInput) (certField, keyField string, err error) {
+	if len(fileInputs) < 2 {
+		return "", "", errors.New("printer: upload: pem import form requires two file fields")
+	}
+
+	// find the key field by its label or name
+	keyIdx := -1
+	for i := range fileInputs {
+		if regexLabelKey.MatchString(fileInputs[i].label) || regexLabelKey.MatchString(fileInputs[i].name) {
+			keyIdx = i
+			break
+		}
+	}
+
+	// no way to tell, assume cert then key (the order the web ui uses)
+	if keyIdx == -1 {
+		return fileInputs[0].name, fileInputs[1].name, nil
+	}
+
+	// cert field is the first other field
+	for i := range fileInputs {
+		if i != keyIdx {
+			return fileInputs[i].name, fileInputs[keyIdx].name, nil
+		}
+	}
+
+	return "", "", errors.New("printer: upload: failed to identify pem import form fields")
+}
+
+// appendPem returns pemBytes with the pem block(s) in next appended, adding a
+// newline between them if pemBytes doesn't end with one (otherwise the next
+// block's BEGIN line would follow the END line on the same line, and the
+// blocks wouldn't decode)
+func appendPem(pemBytes, next []byte) []byte {
+	if len(pemBytes) > 0 && pemBytes[len(pemBytes)-1] != '\n' {
+		pemBytes = append(pemBytes, '\n')
+	}
+
+	return append(pemBytes, next...)
+}
+
+// writeImportFormPem writes the fields of the import form used by models that
+// take separate pem cert and key files (instead of a p12). The fields are
+// written in order (see orderFormParts).
+func writeImportFormPem(formWriter *multipart.Writer, bodyBytes []byte, fileInputs []formFileInput, keyPem, certPem []byte, order []string) error {
+	// sanity check pem before sending it
+	if block, _ := pem.Decode(keyPem); block == nil {
+		return errors.New("printer: key pem block did not decode")
+	}
+	if block, _ := pem.Decode(certPem); block == nil {
+		return errors.New("printer: cert leaf pem block did not decode")
+	}
+
+	certField, keyField, err := pemImportFileFields(fileInputs)
+	if err != nil {
+		return err
+	}
+
+	// hidden fields (includes pageid and CSRFToken)
+	parts := []formPart{}
+	hiddenFields := parseHiddenFormFields(bodyBytes)
+	for _, name := range slices.Sorted(maps.Keys(hiddenFields)) {
+		for _, val := range hiddenFields[name] {
+			parts = append(parts, formPart{name: name, value: val})
+		}
+	}
+
+	// files
+	parts = append(parts,
+		formPart{name: certField, fileName: "cert.pem", content: certPem},
+		formPart{name: keyField, fileName: "key.pem", content: keyPem},
+	)
+
+	// key password (key is not encrypted)
+	for _, field := range parsePasswordFieldNames(bodyBytes) {
+		parts = append(parts, formPart{name: field})
+	}
+
+	return writeFormParts(formWriter, orderFormParts(parts, order))
+}
@@ -0,0 +1,43 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/pem"
+	"testing"
+)
+
+func TestAppendPem(t *testing.T) {
+	_, leafPem := testECKeyCert(t, "printer.example.com")
+	_, caPem := testECKeyCert(t, "ca.example.com")
+
+	tests := []struct {
+		name string
+		a, b []byte
+	}{
+		{"trailing newline", leafPem, caPem},
+		{"no trailing newline", bytes.TrimRight(leafPem, "\n"), caPem},
+		{"neither has a trailing newline", bytes.TrimRight(leafPem, "\n"), bytes.TrimRight(caPem, "\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chainPem := appendPem(bytes.Clone(tt.a), tt.b)
+
+			blocks := 0
+			for rest := chainPem; ; blocks++ {
+				var block *pem.Block
+				block, rest = pem.Decode(rest)
+				if block == nil {
+					break
+				}
+			}
+			if blocks != 2 {
+				t.Errorf("appendPem() decoded to %d blocks, want 2:\n%s", blocks, chainPem)
+			}
+		})
+	}
+
+	if got := appendPem(nil, caPem); !bytes.Equal(got, caPem) {
+		t.Errorf("appendPem(nil) = %q, want %q", got, caPem)
+	}
+}
@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+var (
+	// e.g. `Import failed.` or `Invalid password.`
+	regexImportFailed = regexp.MustCompile(`(?i)(?:import|installation)\s+(?:has\s+)?failed|invalid\s+(?:certificate|password|file)|(?:could|can)\s*not\s+(?:be\s+)?import`)
+	// e.g. `<p class="errorMsg">` or `<div id="status">`
+	regexStatusElementName = regexp.MustCompile(`(?i)error|message|msg|status|result|alert|warning`)
+)
+
+// statusElementTags are the elements a status message may be shown in. the
+// title and headings always are one, the others only if their class or id
+// names a status or message.
+var statusElementTags = []string{"title", "h1", "h2", "h3", "h4", "h5", "h6", "p", "div", "span", "td", "li", "font"}
+
+// isRedirect returns true if the status code is an http redirect
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// getImportResultPage follows the redirect some firmware responds to the
+// import post with (Post/Redirect/Get) and returns the result page
+func (p *printer) getImportResultPage(ctx context.Context, postResp *http.Response) ([]byte, error) {
+	// result page location (relative to the post)
+	u, err := postResp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("printer: upload: failed to follow redirect to result page (%w)", err)
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.underBasePath(u).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read body of response
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPStatusError("get of import result page", resp)
+	}
+
+	return bodyBytes, nil
+}
+
+// statusMessages returns the text of the html response input's title,
+// headings and status or message elements, in page order
+func statusMessages(bodyBytes []byte) []string {
+	messages := []string{}
+	for _, elem := range parseElements(bodyBytes, statusElementTags...) {
+		switch elem.tag {
+		case "title", "h1", "h2", "h3", "h4", "h5", "h6":
+		default:
+			if !regexStatusElementName.MatchString(elem.attrs["class"]) && !regexStatusElementName.MatchString(elem.attrs["id"]) {
+				continue
+			}
+		}
+
+		text := elem.text(bodyBytes)
+		if text != "" {
+			messages = append(messages, text)
+		}
+	}
+
+	return messages
+}
+
+// checkBodyForImportFailed returns an error, including the message, if a
+// status or message element of the import response (or result page) input
+// reports the import failed (matching only those elements, not e.g. help
+// text or scripts elsewhere on the page)
+func checkBodyForImportFailed(bodyBytes []byte) error {
+	for _, statusText := range statusMessages(bodyBytes) {
+		if !regexImportFailed.MatchString(statusText) {
+			continue
+		}
+
+		return fmt.Errorf("printer: upload: printer reported import failed (%s)", truncateStatusText(statusText))
+	}
+
+	return nil
+}
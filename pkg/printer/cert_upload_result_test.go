@@ -0,0 +1,26 @@
+package printer
+
+import "testing"
+
+func TestCheckBodyForImportFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		wantErr bool
+	}{
+		{"success", `<title>Certificate</title><div id="status">The certificate was imported.</div>`, false},
+		{"status element", `<title>Certificate</title><p class="errorMsg">Import failed.</p>`, true},
+		{"heading", `<h2>Invalid password.</h2>`, true},
+		{"help text", `<title>Certificate</title><p>If the password is wrong you will see "Import failed."</p>`, false},
+		{"script", `<script>var msg = "Import failed.";</script><div id="status">OK</div>`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBodyForImportFailed([]byte(tt.page))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkBodyForImportFailed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
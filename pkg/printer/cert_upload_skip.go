@@ -0,0 +1,62 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"log/slog"
+	"strings"
+)
+
+// normalizeSerial returns serial (as shown in the cert list or made by
+// certInfoFromCert) as lowercase hex without separators or leading zeros
+func normalizeSerial(serial string) string {
+	serial = strings.ToLower(serial)
+	serial = strings.NewReplacer(":", "", " ", "", "-", "").Replace(serial)
+
+	return strings.TrimLeft(serial, "0")
+}
+
+// presentCertID returns the ID of the cert on the printer that has the same
+// SHA-256 fingerprint as cert, if there is one. The cert list's metadata
+// narrows down the candidates (by serial, if the list shows it) and each candidate's fingerprint is then checked on its view page. If the view
+// pages don't show fingerprints, a metadata match is accepted on its own.
+func (p *printer) presentCertID(ctx context.Context, cert *x509.Certificate) (string, bool, error) {
+	infos, err := p.listCerts(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	want := certInfoFromCert("", cert)
+	expected := certFingerprint(cert)
+
+	for _, info := range infos {
+		// list shows a different serial? (dates and names aren't compared;
+		// their formatting varies too much by model)
+		if info.Serial != "" && normalizeSerial(info.Serial) != normalizeSerial(want.Serial) {
+			continue
+		}
+
+		bodyBytes, err := p.getCertViewPage(ctx, info.ID)
+		if err != nil {
+			continue
+		}
+
+		fingerprint, ok := parseCertViewFingerprint(bodyBytes)
+		if !ok {
+			// no fingerprint shown; only trust the list if it showed the serial
+			if info.Serial == "" {
+				continue
+			}
+			p.logger.DebugContext(ctx, "printer: cert already present (matched by serial)", slog.String("id", info.ID))
+			return info.ID, true, nil
+		}
+
+		if bytes.Equal(fingerprint, expected) {
+			p.logger.DebugContext(ctx, "printer: cert already present (matched by fingerprint)", slog.String("id", info.ID))
+			return info.ID, true, nil
+		}
+	}
+
+	return "", false, nil
+}
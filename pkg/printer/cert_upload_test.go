@@ -0,0 +1,75 @@
+package printer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUploadNewCertRetryFindsInstalledCert(t *testing.T) {
+	m := newMockPrinter(t, "1")
+
+	// the printer installs the cert, but the POST fails
+	m.onImport = func(w http.ResponseWriter, install func() string) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			install()
+		}()
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}
+
+	p, err := New(m.URL,
+		WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}),
+		WithPollInterval(10*time.Millisecond),
+		WithPollTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	keyPem, certPem := testECKeyCert(t, "printer.example.com")
+	id, err := p.UploadNewCert(keyPem, certPem)
+	if err != nil {
+		t.Fatalf("UploadNewCert() error = %v", err)
+	}
+	if id != "100" {
+		t.Errorf("UploadNewCert() = %q, want %q", id, "100")
+	}
+	if m.importPosts != 1 {
+		t.Errorf("import POSTed %d times, want 1", m.importPosts)
+	}
+}
+
+func TestUploadNewCertRetryRepostsIfNotInstalled(t *testing.T) {
+	m := newMockPrinter(t, "1")
+
+	// the first POST fails without installing the cert
+	m.onImport = func(w http.ResponseWriter, install func() string) {
+		if m.importPosts == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		install()
+	}
+
+	p, err := New(m.URL,
+		WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}),
+		WithPollInterval(10*time.Millisecond),
+		WithPollTimeout(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	keyPem, certPem := testECKeyCert(t, "printer.example.com")
+	id, err := p.UploadNewCert(keyPem, certPem)
+	if err != nil {
+		t.Fatalf("UploadNewCert() error = %v", err)
+	}
+	if id != "100" {
+		t.Errorf("UploadNewCert() = %q, want %q", id, "100")
+	}
+	if m.importPosts != 2 {
+		t.Errorf("import POSTed %d times, want 2", m.importPosts)
+	}
+}
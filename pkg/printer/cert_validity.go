@@ -0,0 +1,49 @@
+package printer
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCertValidityTooLong is returned when a cert's validity period exceeds
+// the maximum set by WithMaxValidity. Some firmware silently rejects such
+// certs, so they are refused before upload.
+var ErrCertValidityTooLong = errors.New("printer: upload: cert validity period is longer than the printer accepts")
+
+// WithMaxValidity sets the longest cert validity period (NotAfter - NotBefore)
+// the printer accepts (e.g. 398 days). Uploading a cert with a longer period
+// returns ErrCertValidityTooLong without contacting the printer.
+func WithMaxValidity(d time.Duration) Option {
+	return func(p *printer) {
+		p.maxValidity = d
+	}
+}
+
+// checkCertValidity returns ErrCertValidityTooLong if the leaf cert in certPem
+// is valid for longer than the printer's max validity (if one is set)
+func (p *printer) checkCertValidity(certPem []byte) error {
+	if p.maxValidity <= 0 {
+		return nil
+	}
+
+	// leaf cert is the first block
+	certPemBlock, _ := pem.Decode(certPem)
+	if certPemBlock == nil {
+		return errors.New("printer: upload: failed to decode cert pem")
+	}
+
+	cert, err := x509.ParseCertificate(certPemBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("printer: upload: failed to parse cert (%w)", err)
+	}
+
+	validity := cert.NotAfter.Sub(cert.NotBefore)
+	if validity > p.maxValidity {
+		return fmt.Errorf("%w (cert is valid for %d days, max is %d days)", ErrCertValidityTooLong, int(validity.Hours()/24), int(p.maxValidity.Hours()/24))
+	}
+
+	return nil
+}
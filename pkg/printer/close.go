@@ -0,0 +1,25 @@
+package printer
+
+// CloseIdleConnections closes the base transport's idle connections, if it
+// supports it
+func (trans *printerTransport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+
+	if base, ok := trans.base.(closeIdler); ok {
+		base.CloseIdleConnections()
+	}
+}
+
+// Close releases the printer's idle (keep-alive) connections. It doesn't log
+// out or prevent further use of the printer (new connections are made as
+// needed). If the transport of the client from WithHTTPClient can't close
+// idle connections, Close does nothing. Note the default transport is shared,
+// so closing its idle connections also closes those of other printers (and
+// other http.DefaultTransport users).
+func (p *printer) Close() error {
+	p.httpClient.CloseIdleConnections()
+
+	return nil
+}
@@ -1,27 +1,119 @@
 package printer
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
 	"regexp"
+	"slices"
 )
 
-var errCSRFTokenNotFound = errors.New("printer: get: failed to find csrf token")
+var (
+	errCSRFTokenNotFound = errors.New("printer: get: failed to find csrf token")
+	errCSRFTokenRejected = errors.New("printer: csrf token rejected (it may have been used by another session)")
+)
+
+// csrfTokenNames are the names of the csrf token field; newer models use
+// `CSRFToken1`
+var csrfTokenNames = []string{"CSRFToken", "CSRFToken1"}
+
+var (
+	// e.g. `var CSRFToken = "JRL[...snip...]bQ==";` or `"CSRFToken1": '...'`
+	regexScriptCSRFToken = regexp.MustCompile(`["']?\b(CSRFToken1?)["']?\s*[=:]\s*["']([^"']+)["']`)
+	// e.g. `Invalid CSRF token.` or `CSRFToken mismatch`
+	regexCSRFTokenRejected = regexp.MustCompile(`(?i)(?:invalid|incorrect|illegal|expired)\s+(?:csrf\s*)?token|csrf\s*token\s+(?:is\s+)?(?:invalid|incorrect|expired|mismatch)`)
+)
+
+// csrfToken is a page's csrf token and the name of its field, which must be
+// sent back with the same name
+type csrfToken struct {
+	name  string
+	value string
+}
+
+// isCSRFTokenName returns true if name is a csrf token field name
+func isCSRFTokenName(name string) bool {
+	return slices.Contains(csrfTokenNames, name)
+}
+
+// hasCSRFToken returns true if the form values include a csrf token
+func hasCSRFToken(values url.Values) bool {
+	for _, name := range csrfTokenNames {
+		if values.Get(name) != "" {
+			return true
+		}
+	}
+
+	return false
+}
 
 // parseBodyForCSRFToken returns the csrfToken contained in the html
-// response input
-func parseBodyForCSRFToken(bodyBytes []byte) (csrfToken string, err error) {
+// response input. The token is usually an input field, but some firmware
+// puts it in a meta tag or a script variable instead, which are checked in
+// that order.
+func parseBodyForCSRFToken(bodyBytes []byte) (csrfToken, error) {
 	// e.g. `<input type="hidden" id="CSRFToken" name="CSRFToken" value="JRL[...snip...]bQ=="/>`
-	regex := regexp.MustCompile(`<input[^>]+(?:id="CSRFToken"[^>]+value="([^"]+)"[^>]*|value="([^"]+)"[^>]+id="CSRFToken"[^>]*)>`)
-	caps := regex.FindSubmatch(bodyBytes)
+	// (attribute order and quoting vary by model)
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if !isCSRFTokenName(attrs["id"]) && !isCSRFTokenName(attrs["name"]) {
+			continue
+		}
+
+		// name is what is submitted
+		name := attrs["name"]
+		if name == "" {
+			name = attrs["id"]
+		}
 
-	// error if wrong length
-	if len(caps) != 3 {
-		return "", errCSRFTokenNotFound
+		if attrs["value"] != "" {
+			return csrfToken{name: name, value: attrs["value"]}, nil
+		}
 	}
 
-	// return the non-empty capture group (either caps[1] or caps[2])
-	if len(caps[1]) > 0 {
-		return string(caps[1]), nil
+	// meta tag, e.g. `<meta name="CSRFToken" content="JRL[...snip...]bQ==">`
+	for _, meta := range parseElements(bodyBytes, "meta") {
+		attrs := meta.attrs
+		if isCSRFTokenName(attrs["name"]) && attrs["content"] != "" {
+			return csrfToken{name: attrs["name"], value: attrs["content"]}, nil
+		}
 	}
-	return string(caps[2]), nil
+
+	// script variable
+	caps := regexScriptCSRFToken.FindSubmatch(bodyBytes)
+	if len(caps) == 3 {
+		return csrfToken{name: string(caps[1]), value: string(caps[2])}, nil
+	}
+
+	// got the login page instead (session ended)?
+	if isLoginPage(bodyBytes) {
+		return csrfToken{}, fmt.Errorf("%w (got login page instead of form)", ErrSessionExpired)
+	}
+
+	return csrfToken{}, fmt.Errorf("%w (tried input field, meta tag, and script variable named %v; %s)", errCSRFTokenNotFound, csrfTokenNames, bodySnippet(bodyBytes))
+}
+
+// checkBodyForCSRFTokenRejected returns errCSRFTokenRejected if the html
+// response input is the page the printer returns when a form is posted with
+// a csrf token that is no longer valid
+func checkBodyForCSRFTokenRejected(bodyBytes []byte) error {
+	if regexCSRFTokenRejected.MatchString(htmlToText(bodyBytes)) {
+		return errCSRFTokenRejected
+	}
+
+	return nil
+}
+
+// withCSRFRetry runs op and, if its form was rejected for its csrf token
+// (e.g. because an admin was using the web UI at the same time), runs it
+// once more, which gets fresh tokens
+func (p *printer) withCSRFRetry(ctx context.Context, op func(ctx context.Context) error) error {
+	err := op(ctx)
+	if !errors.Is(err, errCSRFTokenRejected) {
+		return err
+	}
+
+	p.logger.DebugContext(ctx, "printer: csrf token rejected, retrying with a fresh token")
+	return op(ctx)
 }
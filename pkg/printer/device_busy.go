@@ -0,0 +1,30 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrDeviceBusy is returned when the printer's web UI responds with its busy
+// page (e.g. because someone else is logged in to the single-session web UI)
+// instead of the expected form. The operation can be retried later.
+var ErrDeviceBusy = errors.New("printer: device is busy (another session may be active)")
+
+// e.g. `The device is busy.` or `Another user is currently logged in.`
+var regexDeviceBusy = regexp.MustCompile(`(?i)device\s+is\s+(?:currently\s+)?busy|(?:another|other)\s+(?:user|session|administrator)\s+is\s+(?:currently\s+|already\s+)?(?:logged|active|using|accessing|configuring)|(?:being\s+)?(?:used|configured|accessed)\s+by\s+another\s+(?:user|session)`)
+
+// checkBodyForDeviceBusy returns ErrDeviceBusy, wrapped with the page's text,
+// if the html response input is the printer's busy page. Only pages without
+// the expected form should be checked (a form's help text may mention other
+// sessions).
+func checkBodyForDeviceBusy(bodyBytes []byte) error {
+	// match visible text only (not e.g. messages in the page's scripts)
+	text := htmlToText(bodyBytes)
+	if !regexDeviceBusy.MatchString(text) {
+		return nil
+	}
+
+	// include the page's text to aid diagnosis
+	return fmt.Errorf("%w (%s)", ErrDeviceBusy, truncateStatusText(text))
+}
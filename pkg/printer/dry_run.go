@@ -0,0 +1,160 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ErrDryRun is returned (as a *DryRunPlan) by operations that would change
+// the printer, when it was made with WithDryRun
+var ErrDryRun = errors.New("printer: dry run, no changes made")
+
+// redactedValue replaces secret form values (e.g. CSRFTokens and passwords)
+// in a DryRunPlan
+const redactedValue = "<redacted>"
+
+// WithDryRun makes operations that would change the printer load and parse
+// the pages as usual, but not submit the forms that make changes. Instead
+// they return a *DryRunPlan (which matches ErrDryRun with errors.Is)
+// describing the forms they would have submitted. This covers UploadNewCert,
+// UploadP12, DeleteCert, SetActiveCert, RotateCert (and their variants),
+// SetHTTPSEnabled, SetOCSPSettings, SetDot1xCert, SetSessionTimeout,
+// RebootPrinter, ImportCACert, GenerateCSR, CreateSelfSignedCert and
+// RestoreCertStore. Read-only forms (e.g. the exports of ExportCert and
+// BackupCertStore) and logging in are still submitted.
+func WithDryRun(dryRun bool) Option {
+	return func(p *printer) {
+		p.dryRun = dryRun
+	}
+}
+
+// PlannedRequest is a form submission a dry run skipped
+type PlannedRequest struct {
+	Method string
+	URL    string
+	// Fields are the form's values (by name). Secrets are replaced with
+	// `<redacted>` and files with a description of the file.
+	Fields map[string][]string
+}
+
+// DryRunPlan is what an operation would have done, if not for WithDryRun
+type DryRunPlan struct {
+	// Operation is the operation, e.g. `delete cert`
+	Operation string
+	// CertID is the cert the operation would have changed (if any)
+	CertID string
+	// Requests are the form submissions the operation would have started
+	// with. Submissions that depend on the printer's response to these (e.g.
+	// confirmation pages) can't be known and aren't included.
+	Requests []PlannedRequest
+}
+
+// Error implements error
+func (plan *DryRunPlan) Error() string {
+	if plan.CertID == "" {
+		return fmt.Sprintf("%s (%s, %d request(s) planned)", ErrDryRun, plan.Operation, len(plan.Requests))
+	}
+
+	return fmt.Sprintf("%s (%s id %s, %d request(s) planned)", ErrDryRun, plan.Operation, plan.CertID, len(plan.Requests))
+}
+
+// Unwrap returns ErrDryRun
+func (plan *DryRunPlan) Unwrap() error {
+	return ErrDryRun
+}
+
+// dryRunPlanKey is the context key of the plan of a dry run operation
+type dryRunPlanKey struct{}
+
+// dryRunContext returns ctx with a new plan for the operation, if the printer
+// is in dry run mode
+func (p *printer) dryRunContext(ctx context.Context, operation string, id string) context.Context {
+	if !p.dryRun {
+		return ctx
+	}
+
+	return context.WithValue(ctx, dryRunPlanKey{}, &DryRunPlan{
+		Operation: operation,
+		CertID:    id,
+	})
+}
+
+// dryRunPlan returns the plan of ctx's operation, or nil if it isn't a dry run
+func dryRunPlan(ctx context.Context) *DryRunPlan {
+	plan, _ := ctx.Value(dryRunPlanKey{}).(*DryRunPlan)
+	return plan
+}
+
+// redactFields returns a copy of data with the CSRFToken, fields named as
+// passwords, and the secret fields redacted
+func redactFields(data url.Values, secretFields ...string) map[string][]string {
+	fields := make(map[string][]string, len(data))
+	for name, values := range data {
+		values = slices.Clone(values)
+		if isCSRFTokenName(name) || strings.Contains(strings.ToLower(name), "password") || slices.Contains(secretFields, name) {
+			for i := range values {
+				values[i] = redactedValue
+			}
+		}
+		fields[name] = values
+	}
+
+	return fields
+}
+
+// planForm adds the submission of a url encoded form to plan
+func (plan *DryRunPlan) planForm(u *url.URL, data url.Values) {
+	plan.Requests = append(plan.Requests, PlannedRequest{
+		Method: "POST",
+		URL:    u.String(),
+		Fields: redactFields(data),
+	})
+}
+
+// planMultipartForm adds the submission of a multipart form (e.g. an
+// import) to plan. Files are replaced with their name and size.
+func (plan *DryRunPlan) planMultipartForm(u *url.URL, contentType string, body []byte, secretFields ...string) error {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	data := url.Values{}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() != "" {
+			data.Add(part.FormName(), fmt.Sprintf("<file %s (%d bytes)>", part.FileName(), len(content)))
+		} else {
+			data.Add(part.FormName(), strings.Clone(string(content)))
+		}
+	}
+
+	plan.Requests = append(plan.Requests, PlannedRequest{
+		Method: "POST",
+		URL:    u.String(),
+		Fields: redactFields(data, secretFields...),
+	})
+
+	return nil
+}
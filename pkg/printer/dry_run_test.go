@@ -0,0 +1,85 @@
+package printer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dryRunPage is a settings page with the fields the mutating operations look
+// for
+const dryRunPage = `<html><body><form method="post" enctype="multipart/form-data">
+<input type="hidden" name="pageid" value="1"/>
+<input type="hidden" id="CSRFToken" name="CSRFToken" value="dG9rZW4="/>
+<label for="B8a1">Certificate</label>
+<select id="B8a1" name="B8a1"><option value="0" selected>Preset</option><option value="1">cert-1</option></select>
+<label for="B8b2">Session Timeout</label><input type="text" id="B8b2" name="B8b2" value="10"/> Minutes
+<label for="B8c3">Common Name</label><input type="text" id="B8c3" name="B8c3" value=""/>
+<label for="B8d4">File</label><input type="file" id="B8d4" name="B8d4"/>
+<label for="B8e5">Password</label><input type="password" id="B8e5" name="B8e5"/>
+<input type="checkbox" id="B8f6" name="B8f6" value="1"/><label for="B8f6">Enable OCSP</label>
+<input type="hidden" name="http_page_mode" value="4"/>
+</form></body></html>`
+
+func TestDryRunSubmitsNothing(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts.Add(1)
+		}
+		_, _ = w.Write([]byte(dryRunPage))
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL, WithDryRun(true))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, caPem := testECKeyCert(t, "ca.example.com")
+
+	tests := []struct {
+		name string
+		op   func() error
+	}{
+		{"RebootPrinter", p.RebootPrinter},
+		{"SetOCSPSettings", func() error { return p.SetOCSPSettings(OCSPSettings{OCSP: true}) }},
+		{"SetSessionTimeout", func() error { return p.SetSessionTimeout(30 * time.Minute) }},
+		{"SetDot1xCert", func() error { return p.SetDot1xCert("1") }},
+		{"RestoreCertStore", func() error { return p.RestoreCertStore([]byte("blob"), "secret") }},
+		{"ImportCACert", func() error {
+			_, err := p.ImportCACert(caPem)
+			return err
+		}},
+		{"GenerateCSR", func() error {
+			_, err := p.GenerateCSR(CSRParams{CommonName: "printer.example.com"})
+			return err
+		}},
+		{"CreateSelfSignedCert", func() error {
+			_, err := p.CreateSelfSignedCert(SelfSignedParams{CommonName: "printer.example.com"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts.Store(0)
+
+			err := tt.op()
+			if !errors.Is(err, ErrDryRun) {
+				t.Fatalf("%s() error = %v, want %v", tt.name, err, ErrDryRun)
+			}
+
+			var plan *DryRunPlan
+			if !errors.As(err, &plan) || len(plan.Requests) == 0 {
+				t.Errorf("%s() planned no requests", tt.name)
+			}
+			if got := posts.Load(); got != 0 {
+				t.Errorf("%s() POSTed %d times, want 0", tt.name, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package printer
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// not all models have this page
+const urlErrorLog = "/general/errorlog.html"
+
+var (
+	// e.g. `<tr><td>1</td><td>2025/09/09 10:15:02</td><td>Paper Jam</td></tr>`
+	regexTableRow  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	regexTableCell = regexp.MustCompile(`(?is)<td[^>]*>(.*?)</td>`)
+)
+
+// logTimeLayouts are the formats the log page uses for timestamps
+var logTimeLayouts = []string{
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+}
+
+// LogEntry is an entry of the printer's error log
+type LogEntry struct {
+	// Time is when the error occurred (zero if the log doesn't show it)
+	Time time.Time
+	// Message is the printer's description of the error
+	Message string
+}
+
+// parseLogTime parses s as a log timestamp
+func parseLogTime(s string) (time.Time, bool) {
+	for _, layout := range logTimeLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseErrorLog parses the entries of the error log page. each table row with
+// data cells is an entry; entry numbers are dropped and the remaining cells
+// (other than the timestamp) make up the message.
+func parseErrorLog(bodyBytes []byte) []LogEntry {
+	entries := []LogEntry{}
+	for _, row := range regexTableRow.FindAllSubmatch(bodyBytes, -1) {
+		entry := LogEntry{}
+		parts := []string{}
+		for _, cell := range regexTableCell.FindAllSubmatch(row[1], -1) {
+			text := htmlToText(cell[1])
+			if text == "" {
+				continue
+			}
+
+			if t, ok := parseLogTime(text); ok && entry.Time.IsZero() {
+				entry.Time = t
+				continue
+			}
+
+			// entry number
+			if _, err := strconv.Atoi(text); err == nil && len(parts) == 0 {
+				continue
+			}
+
+			parts = append(parts, text)
+		}
+
+		// header or empty row
+		if len(parts) == 0 {
+			continue
+		}
+
+		entry.Message = strings.Join(parts, " - ")
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// GetErrorLog returns the entries of the printer's error log (in the order
+// the printer lists them), which often explain why an operation failed. If
+// the model doesn't have an error log page, ErrUnsupported is returned.
+func (p *printer) GetErrorLog() ([]LogEntry, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	bodyBytes, err := p.getOptionalPage(ctx, urlErrorLog)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseErrorLog(bodyBytes), nil
+}
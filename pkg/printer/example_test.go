@@ -0,0 +1,105 @@
+package printer_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gregtwallace/brother-cert/pkg/printer"
+)
+
+// exampleImportPage is the import page of the web UI: a multipart form with a
+// CSRF token, a file input for the p12 file, and its password
+const exampleImportPage = `<html><body>
+<form method="post" action="import.html" enctype="multipart/form-data">
+<input type="hidden" id="pageid" name="pageid" value="390"/>
+<input type="hidden" id="CSRFToken" name="CSRFToken" value="dG9rZW4="/>
+<input type="hidden" name="hidden_certificate_process_control" value="1"/>
+<input type="file" id="B820" name="B820"/>
+<input type="password" id="B821" name="B821"/>
+</form></body></html>`
+
+// exampleListRow is a row of the cert list page, which links to each cert's
+// view page by its ID
+const exampleListRow = `<tr><td>cert-%[1]s</td><td>ca</td><td><a href="view.html?idx=%[1]s">View</a></td></tr>`
+
+// Example_uploadNewCert drives UploadNewCert against a test server that
+// returns canned pages of the web UI
+func Example_uploadNewCert() {
+	var mu sync.Mutex
+	certIDs := []string{"1"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/net/security/certificate/certificate.html":
+			rows := ""
+			for _, id := range certIDs {
+				rows += fmt.Sprintf(exampleListRow, id)
+			}
+			fmt.Fprintf(w, "<html><body><table>%s</table></body></html>", rows)
+
+		case r.URL.Path == "/net/security/certificate/import.html" && r.Method == http.MethodGet:
+			fmt.Fprint(w, exampleImportPage)
+
+		case r.URL.Path == "/net/security/certificate/import.html" && r.Method == http.MethodPost:
+			// the printer installs the cert and lists it under a new ID
+			certIDs = append(certIDs, "2")
+			fmt.Fprint(w, "<html><body>Importing. Please wait.</body></html>")
+
+		case r.URL.Path == "/net/security/certificate/view.html":
+			fmt.Fprint(w, "<html><body></body></html>")
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := printer.New(srv.URL, printer.WithHTTPClient(srv.Client()), printer.WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	keyPem, certPem := exampleKeyCert()
+	id, err := p.UploadNewCert(keyPem, certPem)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println("installed cert", id)
+	// Output: installed cert 2
+}
+
+// exampleKeyCert returns the pem of a new key and of a self-signed cert for it
+func exampleKeyCert() (keyPem, certPem []byte) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "printer.example.com"},
+		DNSNames:     []string{"printer.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	certDer, _ := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	keyDer, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	keyPem = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+	certPem = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})
+
+	return keyPem, certPem
+}
@@ -0,0 +1,28 @@
+package printer
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// certFingerprint returns the SHA-256 fingerprint of the certificate
+func certFingerprint(cert *x509.Certificate) []byte {
+	fingerprint := sha256.Sum256(cert.Raw)
+	return fingerprint[:]
+}
+
+// parseFingerprint decodes a hex SHA-256 fingerprint string. Both plain hex
+// and colon separated (e.g. `AB:CD:...`) formats are accepted.
+func parseFingerprint(fingerprint string) ([]byte, error) {
+	cleaned := strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", "")
+
+	fpBytes, err := hex.DecodeString(cleaned)
+	if err != nil || len(fpBytes) != sha256.Size {
+		return nil, fmt.Errorf("printer: invalid sha-256 fingerprint '%s'", fingerprint)
+	}
+
+	return fpBytes, nil
+}
@@ -0,0 +1,383 @@
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var errFileFieldNotFound = errors.New("printer: file upload field not found in form")
+
+var (
+	// e.g. the text between an input and the next tag
+	regexLeadingText = regexp.MustCompile(`^[^<]*`)
+	// e.g. `B8ea` (field names generated by the web UI)
+	regexDynamicFieldName = regexp.MustCompile(`^B[0-9A-Fa-f]{3}$`)
+)
+
+// formCheckbox is a checkbox input parsed from a page
+type formCheckbox struct {
+	name    string
+	value   string
+	checked bool
+	label   string
+}
+
+// formFileInput is a file input parsed from a page
+type formFileInput struct {
+	name  string
+	label string
+}
+
+// htmlElement is an element of a page, found with the html tokenizer (so
+// attribute order, quoting, case and whitespace don't matter)
+type htmlElement struct {
+	// tag is the lower cased tag name
+	tag string
+	// attrs are the attributes of the start tag. names are lower cased and
+	// values are unescaped. bare attributes (e.g. `checked`) are present with
+	// an empty value.
+	attrs map[string]string
+	// start and end are the positions of the start tag in the page, and
+	// contentEnd is where the element's content ends (its end tag, or the end
+	// of the page if it has none)
+	start, end, contentEnd int
+}
+
+// content returns the raw html between the element's start and end tags
+func (e htmlElement) content(bodyBytes []byte) []byte {
+	return bodyBytes[e.end:e.contentEnd]
+}
+
+// text returns the element's content as plain text
+func (e htmlElement) text(bodyBytes []byte) string {
+	return htmlToText(e.content(bodyBytes))
+}
+
+// voidElements have no content or end tag
+var voidElements = []string{"br", "hr", "img", "input", "link", "meta"}
+
+// parseElements returns the elements of the html response input with the
+// specified tag names, in page order. an option is also ended by the next
+// option, since browsers don't require its end tag.
+func parseElements(bodyBytes []byte, tags ...string) []htmlElement {
+	elements := []htmlElement{}
+
+	// indexes of the elements whose end tag hasn't been found yet
+	open := []int{}
+	closeOpen := func(from int, pos int) {
+		for _, i := range open[from:] {
+			elements[i].contentEnd = pos
+		}
+		open = open[:from]
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(bodyBytes))
+	pos := 0
+	for {
+		tokenType := z.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		start := pos
+		pos += len(z.Raw())
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if !slices.Contains(tags, tag) {
+				continue
+			}
+
+			if tag == "option" && len(open) > 0 && elements[open[len(open)-1]].tag == "option" {
+				closeOpen(len(open)-1, start)
+			}
+
+			elements = append(elements, htmlElement{
+				tag:        tag,
+				attrs:      tokenAttrs(z, hasAttr),
+				start:      start,
+				end:        pos,
+				contentEnd: pos,
+			})
+			if tokenType == html.StartTagToken && !slices.Contains(voidElements, tag) {
+				open = append(open, len(elements)-1)
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for i := len(open) - 1; i >= 0; i-- {
+				if elements[open[i]].tag == string(name) {
+					closeOpen(i, start)
+					break
+				}
+			}
+		}
+	}
+	closeOpen(0, len(bodyBytes))
+
+	return elements
+}
+
+// tokenAttrs returns the attributes of the tokenizer's current tag (if it
+// has any)
+func tokenAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+
+		// a browser uses the first of duplicate attributes
+		if _, ok := attrs[string(key)]; !ok {
+			attrs[string(key)] = string(val)
+		}
+	}
+
+	return attrs
+}
+
+// htmlSelect is a select element and its options
+type htmlSelect struct {
+	htmlElement
+	options []htmlElement
+}
+
+// parseSelects returns the select elements of the html response input, with
+// their options, in page order
+func parseSelects(bodyBytes []byte) []htmlSelect {
+	selects := []htmlSelect{}
+	for _, element := range parseElements(bodyBytes, "select", "option") {
+		if element.tag == "select" {
+			selects = append(selects, htmlSelect{htmlElement: element, options: []htmlElement{}})
+			continue
+		}
+
+		last := len(selects) - 1
+		if last >= 0 && element.start < selects[last].contentEnd {
+			selects[last].options = append(selects[last].options, element)
+		}
+	}
+
+	return selects
+}
+
+// parseHiddenFormFields returns the names and values of all of the hidden
+// input fields in the html response input
+func parseHiddenFormFields(bodyBytes []byte) url.Values {
+	fields := url.Values{}
+
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if !strings.EqualFold(attrs["type"], "hidden") || attrs["name"] == "" {
+			continue
+		}
+
+		fields.Add(attrs["name"], attrs["value"])
+	}
+
+	return fields
+}
+
+// parseDynamicHiddenFields returns the names of the hidden input fields in
+// the html response input whose names are generated by the web UI (e.g.
+// `B8ea`), in page order
+func parseDynamicHiddenFields(bodyBytes []byte) []string {
+	names := []string{}
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if !strings.EqualFold(attrs["type"], "hidden") || !regexDynamicFieldName.MatchString(attrs["name"]) {
+			continue
+		}
+
+		if !slices.Contains(names, attrs["name"]) {
+			names = append(names, attrs["name"])
+		}
+	}
+
+	return names
+}
+
+// parseFileFieldName returns the name attribute of the first file input
+// field in the html response input
+func parseFileFieldName(bodyBytes []byte) (string, error) {
+	fileInputs := parseFileInputs(bodyBytes)
+	if len(fileInputs) == 0 {
+		return "", errFileFieldNotFound
+	}
+
+	return fileInputs[0].name, nil
+}
+
+// parseFileInputs returns all of the file inputs in the html response input
+// along with their label text
+func parseFileInputs(bodyBytes []byte) []formFileInput {
+	labels := parseLabels(bodyBytes)
+
+	fileInputs := []formFileInput{}
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if !strings.EqualFold(attrs["type"], "file") || attrs["name"] == "" {
+			continue
+		}
+
+		fileInputs = append(fileInputs, formFileInput{
+			name:  attrs["name"],
+			label: inputLabel(bodyBytes, input.end, attrs["id"], labels),
+		})
+	}
+
+	return fileInputs
+}
+
+// parseLabels returns the text of the label elements in the html response
+// input, by the id of the element they are for
+func parseLabels(bodyBytes []byte) map[string]string {
+	labels := make(map[string]string)
+	for _, label := range parseElements(bodyBytes, "label") {
+		if label.attrs["for"] != "" {
+			labels[label.attrs["for"]] = label.text(bodyBytes)
+		}
+	}
+
+	return labels
+}
+
+// inputLabel returns the label of the input with the specified id, which
+// ends at position end of bodyBytes. The label is either a label element or
+// the text right after the input.
+func inputLabel(bodyBytes []byte, end int, id string, labels map[string]string) string {
+	if id != "" && labels[id] != "" {
+		return labels[id]
+	}
+
+	return htmlToText(regexLeadingText.Find(bodyBytes[end:]))
+}
+
+// precedingLabel returns the label of the field with the specified id, which
+// starts at position start of bodyBytes. The label is either a label element or
+// the text right before the field.
+func precedingLabel(bodyBytes []byte, start int, id string, labels map[string]string) string {
+	if id != "" && labels[id] != "" {
+		return labels[id]
+	}
+
+	before := bodyBytes[:start]
+	return htmlToText(before[bytes.LastIndexByte(before, '>')+1:])
+}
+
+// parseCheckboxes returns all of the checkbox inputs in the html response
+// input along with their current state and label text
+func parseCheckboxes(bodyBytes []byte) []formCheckbox {
+	labels := parseLabels(bodyBytes)
+
+	checkboxes := []formCheckbox{}
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if !strings.EqualFold(attrs["type"], "checkbox") || attrs["name"] == "" {
+			continue
+		}
+
+		// checkbox with no value submits "on"
+		value, hasValue := attrs["value"]
+		if !hasValue {
+			value = "on"
+		}
+
+		_, checked := attrs["checked"]
+		checkboxes = append(checkboxes, formCheckbox{
+			name:    attrs["name"],
+			value:   value,
+			checked: checked,
+			label:   inputLabel(bodyBytes, input.end, attrs["id"], labels),
+		})
+	}
+
+	return checkboxes
+}
+
+// parseFormValues returns the values a browser would submit for the form(s)
+// in the html response input if the user made no changes
+func parseFormValues(bodyBytes []byte) url.Values {
+	values := url.Values{}
+
+	// inputs
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		name := attrs["name"]
+		if name == "" {
+			continue
+		}
+
+		_, checked := attrs["checked"]
+		_, disabled := attrs["disabled"]
+		if disabled {
+			continue
+		}
+
+		switch strings.ToLower(attrs["type"]) {
+		case "checkbox", "radio":
+			if !checked {
+				continue
+			}
+			value, hasValue := attrs["value"]
+			if !hasValue {
+				value = "on"
+			}
+			values.Add(name, value)
+
+		case "", "text", "hidden", "number", "email", "url", "tel":
+			values.Add(name, attrs["value"])
+
+		default:
+			// password, file, submit, button, etc. are never resubmitted
+		}
+	}
+
+	// selects
+	for _, sel := range parseSelects(bodyBytes) {
+		name := sel.attrs["name"]
+		if name == "" || len(sel.options) == 0 {
+			continue
+		}
+
+		// browser submits the selected option, or the first if none are
+		selectedAttrs := sel.options[0].attrs
+		for _, option := range sel.options {
+			if _, selected := option.attrs["selected"]; selected {
+				selectedAttrs = option.attrs
+				break
+			}
+		}
+
+		values.Set(name, selectedAttrs["value"])
+	}
+
+	return values
+}
+
+// parseSelectedOption returns the value of the option marked selected in the
+// select with the specified name. unlike a browser submission, the first
+// option is not assumed if none is marked.
+func parseSelectedOption(bodyBytes []byte, selectName string) (string, bool) {
+	for _, sel := range parseSelects(bodyBytes) {
+		if sel.attrs["name"] != selectName {
+			continue
+		}
+
+		for _, option := range sel.options {
+			if _, selected := option.attrs["selected"]; selected {
+				return option.attrs["value"], true
+			}
+		}
+
+		return "", false
+	}
+
+	return "", false
+}
@@ -0,0 +1,78 @@
+package printer
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestParseElementAttrs(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[string]string
+	}{
+		{
+			tag:  `<input type="hidden" id="pageid" name="pageid" value="390"/>`,
+			want: map[string]string{"type": "hidden", "id": "pageid", "name": "pageid", "value": "390"},
+		},
+		{
+			// attribute order, quoting and case vary by model
+			tag:  `<INPUT value='390' NAME=pageid type="hidden">`,
+			want: map[string]string{"value": "390", "name": "pageid", "type": "hidden"},
+		},
+		{
+			// empty single quoted value and extra whitespace
+			tag:  `<input  type = 'hidden'  name='B8ea'  value='' >`,
+			want: map[string]string{"type": "hidden", "name": "B8ea", "value": ""},
+		},
+		{
+			// bare attribute
+			tag:  `<input type="checkbox" name="B86c" value="1" checked>`,
+			want: map[string]string{"type": "checkbox", "name": "B86c", "value": "1", "checked": ""},
+		},
+		{
+			// quoted '>' and entities
+			tag:  `<input name="CSRFToken" value="a&gt;b>c&amp;d">`,
+			want: map[string]string{"name": "CSRFToken", "value": "a>b>c&d"},
+		},
+	}
+
+	for _, tt := range tests {
+		body := []byte(`<p>x</p>` + tt.tag + `<p>y</p>`)
+		inputs := parseElements(body, "input")
+		if len(inputs) != 1 {
+			t.Errorf("parseElements(%q) found %d inputs, want 1", tt.tag, len(inputs))
+			continue
+		}
+
+		if got := string(body[inputs[0].start:inputs[0].end]); got != tt.tag {
+			t.Errorf("parseElements(%q) tag position covers %q", tt.tag, got)
+		}
+		if !maps.Equal(inputs[0].attrs, tt.want) {
+			t.Errorf("parseElements(%q) attrs = %v, want %v", tt.tag, inputs[0].attrs, tt.want)
+		}
+	}
+}
+
+func TestParseFormValuesSelects(t *testing.T) {
+	// options without end tags, and an option's text containing a tag
+	body := []byte(`<form>
+<select name="B903"><option value="0">Preset<option value="3" selected>my <b>cert</b></select>
+<select name='B904'><option value='1'>one</option><option value='2'>two</option></select>
+<input type='hidden' name='pageid' value='326'>
+</form>`)
+
+	want := url.Values{"B903": {"3"}, "B904": {"1"}, "pageid": {"326"}}
+	if got := parseFormValues(body); !maps.EqualFunc(got, want, slices.Equal[[]string]) {
+		t.Errorf("parseFormValues() = %v, want %v", got, want)
+	}
+
+	selects := parseSelects(body)
+	if len(selects) != 2 || len(selects[0].options) != 2 {
+		t.Fatalf("parseSelects() = %+v, want 2 selects with 2 options each", selects)
+	}
+	if got := selects[0].options[1].text(body); got != "my cert" {
+		t.Errorf("option text = %q, want %q", got, "my cert")
+	}
+}
@@ -0,0 +1,89 @@
+package printer
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"slices"
+)
+
+// formPart is a field of a multipart form (a file if fileName is set)
+type formPart struct {
+	name     string
+	value    string
+	fileName string
+	content  []byte
+}
+
+// parseFormFieldOrder returns the names of the input and select fields in
+// the html response input, in page order (the order a browser submits them)
+func parseFormFieldOrder(bodyBytes []byte) []string {
+	names := []string{}
+	for _, field := range parseElements(bodyBytes, "input", "select") {
+		name := field.attrs["name"]
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// orderFormParts returns parts reordered to follow the field names in order.
+// parts that aren't in order stay right after the part they followed
+// originally (or first, if no part before them is in order).
+func orderFormParts(parts []formPart, order []string) []formPart {
+	type rankedPart struct {
+		rank int
+		part formPart
+	}
+	ranked := []rankedPart{}
+
+	rank := -1
+	for _, part := range parts {
+		if i := slices.Index(order, part.name); i != -1 {
+			rank = i
+		}
+		ranked = append(ranked, rankedPart{rank, part})
+	}
+
+	slices.SortStableFunc(ranked, func(a, b rankedPart) int {
+		return cmp.Compare(a.rank, b.rank)
+	})
+
+	ordered := []formPart{}
+	for _, r := range ranked {
+		ordered = append(ordered, r.part)
+	}
+
+	return ordered
+}
+
+// writeFormParts writes parts to formWriter, in order
+func writeFormParts(formWriter *multipart.Writer, parts []formPart) error {
+	for _, part := range parts {
+		// plain field
+		if part.fileName == "" {
+			err := formWriter.WriteField(part.name, part.value)
+			if err != nil {
+				return fmt.Errorf("printer: upload: failed to write form (%w)", err)
+			}
+			continue
+		}
+
+		// file
+		fileW, err := formWriter.CreateFormFile(part.name, part.fileName)
+		if err != nil {
+			return fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		}
+
+		_, err = io.Copy(fileW, bytes.NewReader(part.content))
+		if err != nil {
+			return fmt.Errorf("printer: upload: failed to write form (%w)", err)
+		}
+	}
+
+	return nil
+}
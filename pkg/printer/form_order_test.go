@@ -0,0 +1,105 @@
+package printer
+
+import (
+	"bytes"
+	"mime/multipart"
+	"slices"
+	"testing"
+	"time"
+)
+
+// reorderedImportPage is an import page whose fields aren't in the order
+// writeImportFormPfx makes them
+const reorderedImportPage = `<form method="post" enctype="multipart/form-data">
+<input type="hidden" name="hidden_cert_import_password" value=""/>
+<input type="hidden" id="CSRFToken" name="CSRFToken" value="dG9rZW4="/>
+<select name="B8a1"><option value="1">one</option></select>
+<input type="password" name="B821"/>
+<input type="file" name="B820"/>
+<input type="hidden" name="pageid" value="390"/>
+<input type="hidden" name="hidden_certificate_process_control" value="1"/>
+</form>`
+
+func TestParseFormFieldOrder(t *testing.T) {
+	want := []string{"hidden_cert_import_password", "CSRFToken", "B8a1", "B821", "B820", "pageid", "hidden_certificate_process_control"}
+
+	got := parseFormFieldOrder([]byte(reorderedImportPage))
+	if !slices.Equal(got, want) {
+		t.Errorf("parseFormFieldOrder() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteImportFormPfxOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []string
+		want  []string
+	}{
+		{
+			name:  "page order",
+			order: parseFormFieldOrder([]byte(reorderedImportPage)),
+			want:  []string{"hidden_cert_import_password", "CSRFToken", "B821", "B820", "pageid", "hidden_certificate_process_control"},
+		},
+		{
+			name:  "no order",
+			order: nil,
+			want:  []string{"pageid", "CSRFToken", "hidden_certificate_process_control", "B820", "B821", "hidden_cert_import_password"},
+		},
+		{
+			// fields not on the page stay after the field they followed
+			name:  "partial order",
+			order: []string{"B821", "pageid"},
+			want:  []string{"B821", "hidden_cert_import_password", "pageid", "CSRFToken", "hidden_certificate_process_control", "B820"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			formWriter := multipart.NewWriter(&body)
+
+			err := writeImportFormPfx(formWriter, csrfToken{name: "CSRFToken", value: "dG9rZW4="}, nil, []byte("p12"), "B821", "secret", tt.order)
+			if err != nil {
+				t.Fatalf("writeImportFormPfx() error = %v", err)
+			}
+			err = formWriter.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := multipartFieldNames(t, body.Bytes(), formWriter.Boundary())
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("form fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadNewCertFormOrder(t *testing.T) {
+	m := newMockPrinter(t, "1")
+
+	p, err := New(m.URL, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	keyPem, certPem := testECKeyCert(t, "printer.example.com")
+	_, err = p.UploadNewCert(keyPem, certPem)
+	if err != nil {
+		t.Fatalf("UploadNewCert() error = %v", err)
+	}
+
+	// the fields are posted in the order they are on the page
+	if len(m.importFields) == 0 {
+		t.Fatal("no import form posted")
+	}
+	pageOrder := parseFormFieldOrder([]byte(mockImportPage))
+	last := -1
+	for _, name := range m.importFields {
+		i := slices.Index(pageOrder, name)
+		if i < last {
+			t.Fatalf("form fields %q aren't in page order %q", m.importFields, pageOrder)
+		}
+		last = i
+	}
+}
@@ -0,0 +1,94 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrUnreachable is returned by HealthCheck when the printer can't be
+	// connected to (or doesn't respond)
+	ErrUnreachable = errors.New("printer: health check: printer unreachable")
+
+	// ErrAuthRequired is returned by HealthCheck when the printer requires a
+	// login and logging in (again) failed or no password is set
+	ErrAuthRequired = errors.New("printer: health check: login required")
+
+	// ErrPageUnparseable is returned by HealthCheck when the import page
+	// doesn't have the expected csrf token or form fields (e.g. the firmware
+	// isn't supported)
+	ErrPageUnparseable = errors.New("printer: health check: import page not recognized")
+)
+
+// HealthCheck fetches the printer's certificate import page and confirms its
+// csrf token and form fields can be parsed, without uploading anything. The
+// error (if any) wraps ErrUnreachable, ErrAuthRequired, ErrPageUnparseable,
+// or ErrDeviceInMaintenance, so targets can be categorized.
+func (p *printer) HealthCheck() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	err := p.withSessionRetry(ctx, p.healthCheck)
+	if errors.Is(err, ErrSessionExpired) {
+		return fmt.Errorf("%w (%s)", ErrAuthRequired, err)
+	}
+
+	return err
+}
+
+// healthCheck performs HealthCheck using ctx
+func (p *printer) healthCheck(ctx context.Context) error {
+	bodyBytes, err := p.getImportPage(ctx)
+	if err != nil {
+		return healthCheckError(err)
+	}
+
+	_, err = parseBodyForCSRFToken(bodyBytes)
+	if err != nil {
+		return healthCheckError(err)
+	}
+
+	format, err := parseImportFormat(bodyBytes)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", ErrPageUnparseable, err)
+	}
+
+	// p12 import needs somewhere to put the password
+	if format == ImportFormatPfx {
+		_, err = parsePasswordFieldName(bodyBytes)
+		if err != nil {
+			return fmt.Errorf("%w (%s)", ErrPageUnparseable, err)
+		}
+	}
+
+	return nil
+}
+
+// healthCheckError returns err wrapped with the health check error of its
+// category
+func healthCheckError(err error) error {
+	// already categorized (session expiry is retried by withSessionRetry)
+	if errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrDeviceInMaintenance) || errors.Is(err, ErrDeviceBusy) {
+		return err
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("%w (%s)", ErrAuthRequired, err)
+		}
+
+		return fmt.Errorf("%w (%s)", ErrPageUnparseable, err)
+	}
+
+	if errors.Is(err, errCSRFTokenNotFound) {
+		return fmt.Errorf("%w (%s)", ErrPageUnparseable, err)
+	}
+
+	return fmt.Errorf("%w (%s)", ErrUnreachable, err)
+}
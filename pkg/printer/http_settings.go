@@ -1,31 +1,297 @@
 package printer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 )
 
 const urlHttpCertServerSettings = "net/net/certificate/http.html"
 
 var (
 	errCurrentCertIdNotFound = errors.New("printer: get: failed to find current cert id")
+	errCertSelectNotFound    = errors.New("printer: failed to find cert select field on http settings page")
 )
 
+// ErrActiveCertStaged is returned when the cert change was submitted, but
+// ctx was done before it was confirmed (which restarts the printer to apply
+// it). The change is staged as if SkipReboot was set (in memory only):
+// RebootPrinter on the same printer value applies it, and SetActiveCert (or
+// another http settings change) replaces it.
+var ErrActiveCertStaged = errors.New("printer: cert change submitted but not confirmed (RebootPrinter applies it)")
+
+// default field names (MFC-L2710DW), preferred when they are on the page (and
+// used if the page can't be parsed for the fields at all)
+const (
+	defaultCertSelectField = "B903"
+	defaultHttpsWebField   = "B86c"
+	defaultHttpsIppField   = "B87e"
+)
+
+// httpSettingsFormFields are the fields of the http settings page that are
+// used to change the active cert
+type httpSettingsFormFields struct {
+	certSelectField string
+	// httpsFields are the checkboxes to enable https for each protocol, in page
+	// order (typically WebUI, IPP, and then any others such as Web Services)
+	httpsFields []formCheckbox
+	// fallbacks describe the fields that weren't found by their default name
+	// (and were found by label or position instead), e.g. for logging
+	fallbacks []string
+}
+
+// SetActiveCertOptions modifies the behavior of SetActiveCertWithOptions
+type SetActiveCertOptions struct {
+	// HTTPSFields lists the https checkboxes to enable, by either field name or
+	// (part of) label (e.g. "IPP"). If empty, the first two checkboxes (WebUI
+	// and IPP) are enabled. Checkboxes that aren't listed are not submitted.
+	HTTPSFields []string
+
+	// Services lists the services to bind to the cert. WebUI and IPP are
+	// enabled on the http settings page (in addition to any HTTPSFields), and
+	// the others have their own pages which are submitted first so the printer
+	// only restarts once. If empty, only the http settings page is changed.
+	Services []Service
+
+	// AutoRollback captures the http settings before the change and, if the
+	// printer isn't serving the new cert within RollbackTimeout of the
+	// restart, resubmits them (which restarts the printer again). Only the
+	// http settings page is rolled back, not other Services pages.
+	AutoRollback    bool
+	RollbackTimeout time.Duration
+
+	// PreserveHTTPS resubmits the https checkboxes as they currently are,
+	// instead of enabling WebUI and IPP, so only the cert changes. Any
+	// HTTPSFields are enabled in addition, and NoWebUIHTTPS and NoIPPHTTPS
+	// still apply.
+	PreserveHTTPS bool
+
+	// NoWebUIHTTPS and NoIPPHTTPS leave https for the web UI or IPP out of
+	// the enabled checkboxes (which are otherwise enabled by default)
+	NoWebUIHTTPS bool
+	NoIPPHTTPS   bool
+
+	// NoActivateOtherProtocols confirms the change without also activating
+	// the other secure protocols (http_page_mode 4 instead of 5)
+	NoActivateOtherProtocols bool
+
+	// SkipReboot submits the change but not its confirmation, which would
+	// restart the printer. The change is staged in memory and applied by
+	// RebootPrinter on the same printer value, so the process that staged it
+	// must also call RebootPrinter: if it exits (or another process reboots
+	// the printer), the staged change is lost. It can't be combined with
+	// AutoRollback.
+	SkipReboot bool
+}
+
+// confirmMode returns the http_page_mode to confirm the change with
+func (opts SetActiveCertOptions) confirmMode() string {
+	// 4 == do NOT activate other secure protos
+	if opts.NoActivateOtherProtocols {
+		return "4"
+	}
+
+	// 5 == DO activate other secure protos
+	return "5"
+}
+
+// defaultRollbackTimeout is used if AutoRollback is set without a timeout
+const defaultRollbackTimeout = 3 * time.Minute
+
+// rollbackTimeout returns the RollbackTimeout or the default if not set
+func (opts SetActiveCertOptions) rollbackTimeout() time.Duration {
+	if opts.RollbackTimeout <= 0 {
+		return defaultRollbackTimeout
+	}
+
+	return opts.RollbackTimeout
+}
+
+// parseHttpSettingsFormFields parses the http settings page for the cert
+// select field and the https protocol checkboxes
+func parseHttpSettingsFormFields(bodyBytes []byte) (httpSettingsFormFields, error) {
+	fields := httpSettingsFormFields{}
+
+	// cert select is the default field, else the dropdown labeled as a
+	// certificate
+	selectNames := []string{}
+	for _, sel := range parseElements(bodyBytes, "select") {
+		selectNames = append(selectNames, sel.attrs["name"])
+	}
+	if slices.Contains(selectNames, defaultCertSelectField) {
+		fields.certSelectField = defaultCertSelectField
+	} else {
+		fields.certSelectField = parseCertSelectName(bodyBytes)
+		if fields.certSelectField == "" {
+			return httpSettingsFormFields{}, errCertSelectNotFound
+		}
+		fields.fallbacks = append(fields.fallbacks, fmt.Sprintf("cert select %s (not %s)", fields.certSelectField, defaultCertSelectField))
+	}
+
+	fields.httpsFields = parseHttpsCheckboxes(bodyBytes)
+
+	for _, service := range []Service{ServiceWebUI, ServiceIPP} {
+		checkbox, how := fields.serviceCheckbox(service)
+		if how != "" {
+			fields.fallbacks = append(fields.fallbacks, fmt.Sprintf("%s https checkbox %s (by %s)", service, checkbox.name, how))
+		}
+	}
+
+	return fields, nil
+}
+
+// parseHttpsCheckboxes returns the https protocol checkboxes of the http
+// settings page, which are all of the page's checkboxes except ocsp options
+func parseHttpsCheckboxes(bodyBytes []byte) []formCheckbox {
+	checkboxes := []formCheckbox{}
+	for _, checkbox := range parseCheckboxes(bodyBytes) {
+		if regexLabelOCSP.MatchString(checkbox.label) || regexLabelStapling.MatchString(checkbox.label) {
+			continue
+		}
+
+		checkboxes = append(checkboxes, checkbox)
+	}
+
+	return checkboxes
+}
+
+// httpsFieldsToEnable returns the https checkboxes to enable based on the
+// fields found on the page and the requested list (if any)
+func (fields httpSettingsFormFields) httpsFieldsToEnable(requested []string) ([]formCheckbox, error) {
+	// default: WebUI and IPP
+	if len(requested) == 0 {
+		enable := []formCheckbox{}
+		for _, service := range []Service{ServiceWebUI, ServiceIPP} {
+			checkbox, how := fields.serviceCheckbox(service)
+			if how == "none" || slices.ContainsFunc(enable, func(c formCheckbox) bool { return c.name == checkbox.name }) {
+				continue
+			}
+			enable = append(enable, checkbox)
+		}
+
+		return enable, nil
+	}
+
+	// caller specified subset
+	enable := []formCheckbox{}
+	for _, req := range requested {
+		found := false
+		for _, checkbox := range fields.httpsFields {
+			if checkbox.name == req || strings.Contains(strings.ToLower(checkbox.label), strings.ToLower(req)) {
+				enable = append(enable, checkbox)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("printer: https checkbox '%s' not found on http settings page", req)
+		}
+	}
+
+	return enable, nil
+}
+
+// checkedHttpsFields returns the https checkboxes that are currently enabled,
+// along with the requested ones (if any)
+func (fields httpSettingsFormFields) checkedHttpsFields(requested []string) ([]formCheckbox, error) {
+	// can't preserve what isn't known
+	if len(fields.httpsFields) == 0 {
+		return nil, errors.New("printer: can't preserve https settings (no https checkboxes found on http settings page)")
+	}
+
+	enable := []formCheckbox{}
+	for _, checkbox := range fields.httpsFields {
+		if checkbox.checked {
+			enable = append(enable, checkbox)
+		}
+	}
+
+	if len(requested) == 0 {
+		return enable, nil
+	}
+
+	requestedFields, err := fields.httpsFieldsToEnable(requested)
+	if err != nil {
+		return nil, err
+	}
+	for _, checkbox := range requestedFields {
+		if !slices.ContainsFunc(enable, func(c formCheckbox) bool { return c.name == checkbox.name }) {
+			enable = append(enable, checkbox)
+		}
+	}
+
+	return enable, nil
+}
+
+// serviceCheckbox returns the https checkbox of the service (WebUI or IPP):
+// the one with its default name, else the one with its label, else the one in
+// its usual position on the page. how is "" if it has its default name (or
+// the page has no checkboxes, so the default is returned), "label" or
+// "position" if found that way, and "none" if it isn't on the page.
+func (fields httpSettingsFormFields) serviceCheckbox(service Service) (checkbox formCheckbox, how string) {
+	// WebUI is first and IPP second
+	pos, defaultName := 0, defaultHttpsWebField
+	if service == ServiceIPP {
+		pos, defaultName = 1, defaultHttpsIppField
+	}
+
+	if len(fields.httpsFields) == 0 {
+		return formCheckbox{name: defaultName, value: "1"}, ""
+	}
+
+	for _, checkbox := range fields.httpsFields {
+		if checkbox.name == defaultName {
+			return checkbox, ""
+		}
+	}
+
+	for _, checkbox := range fields.httpsFields {
+		if strings.Contains(strings.ToLower(checkbox.label), strings.ToLower(serviceHttpsLabels[service])) {
+			return checkbox, "label"
+		}
+	}
+
+	if pos < len(fields.httpsFields) {
+		return fields.httpsFields[pos], "position"
+	}
+
+	return formCheckbox{name: defaultName, value: "1"}, "none"
+}
+
+// serviceCheckboxName returns the name of the https checkbox of the service
+// (WebUI or IPP), see serviceCheckbox
+func (fields httpSettingsFormFields) serviceCheckboxName(service Service) string {
+	checkbox, _ := fields.serviceCheckbox(service)
+	return checkbox.name
+}
+
+// logFieldFallbacks logs the http settings fields that weren't found by their
+// default name, so a model whose page differs can be diagnosed
+func (p *printer) logFieldFallbacks(ctx context.Context, fields httpSettingsFormFields) {
+	for _, fallback := range fields.fallbacks {
+		attrs := p.transport.appendCorrelationID([]slog.Attr{slog.String("field", fallback)})
+		p.logger.LogAttrs(ctx, slog.LevelWarn, "printer: http settings field not found by default name, used fallback", attrs...)
+	}
+}
+
 // getHttpSettings fetches the HTTP Server Settings page
-func (p *printer) getHttpSettings() ([]byte, error) {
+func (p *printer) getHttpSettings(ctx context.Context) ([]byte, error) {
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return nil, err
 	}
-	u.Path = urlHttpCertServerSettings
+	u.Path = p.urlPath(urlHttpCertServerSettings)
 
 	// make and do request
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -42,117 +308,392 @@ func (p *printer) getHttpSettings() ([]byte, error) {
 		return nil, err
 	}
 
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("printer: get of http settings page failed (status code %d)", resp.StatusCode)
+		return nil, newHTTPStatusError("get of http settings page", resp)
 	}
 
 	return bodyBytes, nil
 }
 
 // SetActiveCert sets the printers active certificate the specified ID and
-// then restarts the printer (to make the new cert active)
+// then restarts the printer (to make the new cert active). It returns once the
+// restart is confirmed, without waiting for the printer to come back (see
+// WaitForOnline).
 // Note: This function even works of the `id` is not in the dropdown box of the printer's
 // cert picker (which happens when the cert does not have a Common Name)
+// If the cert list shows the cert has no private key, ErrCertNoPrivateKey is
+// returned without changing anything.
 func (p *printer) SetActiveCert(id string) error {
+	return p.SetActiveCertWithOptions(id, SetActiveCertOptions{})
+}
+
+// SetActiveCertContext performs SetActiveCert using ctx. If ctx is done
+// before the change is submitted, nothing is changed and ctx's error is
+// returned. If ctx is done after the change was submitted but before it was
+// confirmed, the change is staged and an error wrapping both
+// ErrActiveCertStaged and ctx's error is returned.
+func (p *printer) SetActiveCertContext(ctx context.Context, id string) error {
+	return p.setActiveCertContext(ctx, id, SetActiveCertOptions{})
+}
+
+// SetActiveCertWithOptions sets the printers active certificate to the
+// specified ID, using the specified options, and then restarts the printer
+func (p *printer) SetActiveCertWithOptions(id string, opts SetActiveCertOptions) error {
+	return p.setActiveCertContext(context.Background(), id, opts)
+}
+
+// setActiveCertContext performs setActiveCert bounded by ctx and the
+// operation budget, and reports cancellation as ctx's error (unless the
+// change was staged)
+func (p *printer) setActiveCertContext(ctx context.Context, id string, opts SetActiveCertOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+
+	err := p.withCSRFRetry(ctx, func(ctx context.Context) error {
+		return p.setActiveCert(p.dryRunContext(ctx, "set active cert", id), id, opts)
+	})
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ErrActiveCertStaged) {
+		return fmt.Errorf("printer: set active cert: %w", ctx.Err())
+	}
+
+	return err
+}
+
+// setActiveCert performs SetActiveCertWithOptions using ctx
+func (p *printer) setActiveCert(ctx context.Context, id string, opts SetActiveCertOptions) error {
+	if opts.SkipReboot && opts.AutoRollback {
+		return errors.New("printer: auto rollback requires the printer to reboot")
+	}
+
+	// refuse public-only certs before changing anything
+	err := p.checkCertHasPrivateKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// get the forms of services that have their own page, and add the https
+	// checkboxes for the others (copied, so the caller's slice isn't changed).
+	// nothing is submitted until all of the pages have been validated.
+	httpsLabels := slices.Clone(opts.HTTPSFields)
+	serviceForms := []certSelectForm{}
+	formServices := []Service{}
+	for _, service := range opts.Services {
+		if label, ok := serviceHttpsLabels[service]; ok {
+			httpsLabels = append(httpsLabels, label)
+			continue
+		}
+
+		path, ok := serviceCertPages[service]
+		if !ok {
+			return fmt.Errorf("printer: unknown service '%s'", service)
+		}
+
+		form, err := p.getCertSelectForm(ctx, path, id)
+		if err != nil {
+			return fmt.Errorf("printer: failed to bind cert to %s (%w)", service, err)
+		}
+		serviceForms = append(serviceForms, form)
+		formServices = append(formServices, service)
+	}
+
 	// GET http settings
-	bodyBytes, err := p.getHttpSettings()
+	p.progress(ProgressFetchingHttpSettings, 10)
+	bodyBytes, err := p.getHttpSettings(ctx)
 	if err != nil {
 		return err
 	}
 
+	p.logForm(ctx, urlHttpCertServerSettings, bodyBytes)
+
 	// find CSRFToken
 	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
 	if err != nil {
 		return err
 	}
 
+	// find form fields
+	fields, err := parseHttpSettingsFormFields(bodyBytes)
+	if err != nil {
+		return err
+	}
+	p.logFieldFallbacks(ctx, fields)
+
+	// keep the current https settings, or enable the requested ones
+	var httpsFields []formCheckbox
+	if opts.PreserveHTTPS {
+		httpsFields, err = fields.checkedHttpsFields(httpsLabels)
+	} else {
+		httpsFields, err = fields.httpsFieldsToEnable(httpsLabels)
+	}
+	if err != nil {
+		return err
+	}
+
+	// leave out web ui / ipp?
+	excluded := []string{}
+	if opts.NoWebUIHTTPS {
+		excluded = append(excluded, fields.serviceCheckboxName(ServiceWebUI))
+	}
+	if opts.NoIPPHTTPS {
+		excluded = append(excluded, fields.serviceCheckboxName(ServiceIPP))
+	}
+	httpsFields = slices.DeleteFunc(slices.Clone(httpsFields), func(checkbox formCheckbox) bool {
+		return slices.Contains(excluded, checkbox.name)
+	})
+
+	// current state of the form, for rollback
+	origData := parseFormValues(bodyBytes)
+
 	// submit initial form to change the cert
-	data := url.Values{}
+	// the page's other fields (e.g. port numbers) are resubmitted as they are,
+	// since omitting them may reset them to their defaults
+	data := parseFormValues(bodyBytes)
 	data.Set("pageid", "326")
-	data.Set("CSRFToken", csrfToken)
-	data.Set("B903", id)
-	// B91d always seems to be 1, but wasn't needed here
-	// Enable HTTPS for WebUI and IPP
-	data.Set("B86c", "1")
-	data.Set("B87e", "1")
-	// there are some other values here but don't set them (which should
-	// leave them as-is in most cases)
+	data.Set(csrfToken.name, csrfToken.value)
+	data.Set(fields.certSelectField, id)
+	// Enable HTTPS for the requested protocols (default WebUI and IPP, or those
+	// already enabled if preserving), and no others
+	for _, checkbox := range fields.httpsFields {
+		data.Del(checkbox.name)
+	}
+	for _, checkbox := range httpsFields {
+		data.Set(checkbox.name, checkbox.value)
+	}
+
+	// serial of the new cert, to confirm it is served after the restart
+	var newSerial []byte
+	if opts.AutoRollback {
+		newSerial, err = p.getCertIDSerial(ctx, id)
+		if err != nil {
+			return fmt.Errorf("printer: failed to get serial of cert %s for rollback check (%w)", id, err)
+		}
+	}
+
+	// bind services that have their own page
+	for i, form := range serviceForms {
+		err = p.postCertSelectForm(ctx, form)
+		if err != nil {
+			return fmt.Errorf("printer: failed to bind cert to %s (%w)", formServices[i], err)
+		}
+	}
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		u, err := url.ParseRequestURI(p.baseUrl)
+		if err != nil {
+			return err
+		}
+		u.Path = p.urlPath(urlHttpCertServerSettings)
+
+		plan.planForm(u, data)
+		return plan
+	}
 
+	// submit form and confirm (which restarts the printer)
+	p.progress(ProgressSubmitting, 30)
+	confirmBody, err := p.postHttpSettingsForm(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	// stage only; RebootPrinter submits it again with the confirmation
+	staged := &stagedHttpSettings{
+		data: data,
+		mode: opts.confirmMode(),
+	}
+	if opts.SkipReboot {
+		p.stagedHttpSettings = staged
+		p.progress(ProgressDone, 100)
+		return nil
+	}
+
+	// caller's ctx done between the POSTs? leave the change staged
+	if ctx.Err() != nil {
+		p.stagedHttpSettings = staged
+		return fmt.Errorf("%w (%w)", ErrActiveCertStaged, ctx.Err())
+	}
+
+	p.progress(ProgressWaitingForDevice, 60)
+	err = p.confirmHttpSettings(ctx, confirmBody, opts.confirmMode())
+	if err != nil {
+		return err
+	}
+
+	// applied; any earlier staged change was replaced
+	p.stagedHttpSettings = nil
+
+	// confirm the new cert is being served, else roll back
+	if opts.AutoRollback {
+		p.progress(ProgressVerifying, 90)
+		verifyCtx, cancelVerify := context.WithTimeout(ctx, opts.rollbackTimeout())
+		lastResult, err := p.awaitServedSerial(verifyCtx, newSerial, func(int) time.Duration { return awaitActiveCertInterval })
+		cancelVerify()
+		if err != nil {
+			err = fmt.Errorf("printer: timed out after %s waiting for new cert to be served (%s)", opts.rollbackTimeout(), lastResult)
+			rollbackErr := p.rollbackHttpSettings(ctx, origData)
+			if rollbackErr != nil {
+				return fmt.Errorf("printer: new cert not confirmed (%w) and rollback failed (%s)", err, rollbackErr)
+			}
+
+			return fmt.Errorf("printer: new cert not confirmed, rolled back to previous http settings (%w)", err)
+		}
+	}
+
+	p.progress(ProgressDone, 100)
+	return nil
+}
+
+// postHttpSettingsForm submits the http settings form with the specified
+// values. The printer responds with a confirmation page, which is returned.
+// Nothing is applied until the confirmation page is submitted.
+func (p *printer) postHttpSettingsForm(ctx context.Context, data url.Values) ([]byte, error) {
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	u.Path = p.urlPath(urlHttpCertServerSettings)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		plan.planForm(u, data)
+		return nil, plan
 	}
-	u.Path = urlHttpCertServerSettings
 
 	// make and do request
-	req, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// read body of response
-	bodyBytes, err = io.ReadAll(resp.Body)
+	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// token used by another session?
+	err = checkBodyForCSRFTokenRejected(bodyBytes)
+	if err != nil {
+		return nil, err
 	}
 
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("printer: failed to post set active cert form")
+		return nil, newHTTPStatusError("post of http settings form", resp)
 	}
 
+	return bodyBytes, nil
+}
+
+// confirmHttpSettings submits the confirmation page returned by
+// postHttpSettingsForm, which applies the changes and restarts the printer.
+// mode 4 == do NOT activate other secure protos, 5 == DO activate them.
+func (p *printer) confirmHttpSettings(ctx context.Context, confirmBody []byte, mode string) error {
 	// find next CSRFToken
-	csrfToken, err = parseBodyForCSRFToken(bodyBytes)
+	csrfToken, err := parseBodyForCSRFToken(confirmBody)
 	if err != nil {
 		return err
 	}
 
 	// submit confirmation (& reboot now)
-	data = url.Values{}
+	data := url.Values{}
 	data.Set("pageid", "326")
-	data.Set("CSRFToken", csrfToken)
-	// 4 == do NOT activate other secure protos
-	// 5 == DO activate other secure protos
-	data.Set("http_page_mode", "5")
+	data.Set(csrfToken.name, csrfToken.value)
+	data.Set("http_page_mode", mode)
 
 	// get url & set path
-	u, err = url.ParseRequestURI(p.baseUrl)
+	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return err
 	}
-	u.Path = urlHttpCertServerSettings
+	u.Path = p.urlPath(urlHttpCertServerSettings)
 
 	// make and do request
-	req, err = http.NewRequest(http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err = p.httpClient.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	// read body of response
-	_, _ = io.Copy(io.Discard, resp.Body)
+	// read body of response (the printer may restart before it is complete)
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	// token used by another session?
+	err = checkBodyForCSRFTokenRejected(bodyBytes)
+	if err != nil {
+		return err
+	}
 
 	// OK status?
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("printer: failed to post set active cert form")
+		return newHTTPStatusError("post of http settings confirmation", resp)
 	}
 
 	return nil
 }
+
+// rollbackHttpSettings logs in again (the printer has restarted) and
+// resubmits the previously captured http settings form values
+func (p *printer) rollbackHttpSettings(ctx context.Context, origData url.Values) error {
+	err := p.login(ctx, p.password)
+	if err != nil {
+		return err
+	}
+
+	// GET http settings (for a fresh CSRFToken)
+	bodyBytes, err := p.getHttpSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+	if err != nil {
+		return err
+	}
+
+	data := url.Values{}
+	for name, vals := range origData {
+		data[name] = vals
+	}
+	data.Set(csrfToken.name, csrfToken.value)
+
+	confirmBody, err := p.postHttpSettingsForm(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	// 4 == do NOT activate other secure protos
+	return p.confirmHttpSettings(ctx, confirmBody, "4")
+}
@@ -0,0 +1,106 @@
+package printer
+
+import (
+	"context"
+	"net/url"
+)
+
+// SetHTTPSEnabled enables or disables https for the web UI and for IPP on the
+// http settings page, leaving the other settings (including the active cert)
+// as they are, and then restarts the printer. Disabling https for the web UI
+// lets it be used over http again (e.g. when decommissioning a printer). It
+// returns once the restart is confirmed, without waiting for the printer to
+// come back (see WaitForOnline).
+func (p *printer) SetHTTPSEnabled(web, ipp bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.withCSRFRetry(ctx, func(ctx context.Context) error {
+		return p.setHTTPSEnabled(p.dryRunContext(ctx, "set https enabled", ""), web, ipp)
+	})
+}
+
+// setHTTPSEnabled performs SetHTTPSEnabled using ctx
+func (p *printer) setHTTPSEnabled(ctx context.Context, web, ipp bool) error {
+	// GET http settings
+	p.progress(ProgressFetchingHttpSettings, 10)
+	bodyBytes, err := p.getHttpSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.logForm(ctx, urlHttpCertServerSettings, bodyBytes)
+
+	// find CSRFToken
+	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+	if err != nil {
+		return err
+	}
+
+	// find form fields
+	fields, err := parseHttpSettingsFormFields(bodyBytes)
+	if err != nil {
+		return err
+	}
+	p.logFieldFallbacks(ctx, fields)
+
+	// form values are the page's current values with the checkboxes changed
+	// (an unchecked checkbox isn't submitted)
+	data := parseFormValues(bodyBytes)
+	data.Set("pageid", "326")
+	data.Set(csrfToken.name, csrfToken.value)
+	for _, change := range []struct {
+		service Service
+		enabled bool
+	}{
+		{ServiceWebUI, web},
+		{ServiceIPP, ipp},
+	} {
+		name := fields.serviceCheckboxName(change.service)
+		if !change.enabled {
+			data.Del(name)
+			continue
+		}
+
+		value := "1"
+		for _, checkbox := range fields.httpsFields {
+			if checkbox.name == name {
+				value = checkbox.value
+				break
+			}
+		}
+		data.Set(name, value)
+	}
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		u, err := url.ParseRequestURI(p.baseUrl)
+		if err != nil {
+			return err
+		}
+		u.Path = p.urlPath(urlHttpCertServerSettings)
+
+		plan.planForm(u, data)
+		return plan
+	}
+
+	// submit form and confirm (which restarts the printer)
+	// 4 == do NOT activate other secure protos
+	p.progress(ProgressSubmitting, 30)
+	confirmBody, err := p.postHttpSettingsForm(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	p.progress(ProgressWaitingForDevice, 60)
+	err = p.confirmHttpSettings(ctx, confirmBody, "4")
+	if err != nil {
+		return err
+	}
+
+	p.progress(ProgressDone, 100)
+	return nil
+}
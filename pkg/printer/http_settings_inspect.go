@@ -0,0 +1,107 @@
+package printer
+
+import "context"
+
+// HTTPSCheckbox is an https protocol checkbox of the http settings page
+type HTTPSCheckbox struct {
+	Name    string
+	Label   string
+	Checked bool
+
+	// Found is false if the checkbox isn't on the page (Name is then the
+	// default name SetActiveCert would submit)
+	Found bool
+}
+
+// HTTPSettingsInspection is what was found on the http settings page, as
+// SetActiveCert would use it
+type HTTPSettingsInspection struct {
+	// CertSelectField is the name of the cert dropdown ("" if not found) and
+	// SelectedCertID is its selected option ("" if none)
+	CertSelectField string
+	SelectedCertID  string
+
+	// HTTPSWebField and HTTPSIPPField are the checkboxes that enable https for
+	// the web UI and IPP
+	HTTPSWebField HTTPSCheckbox
+	HTTPSIPPField HTTPSCheckbox
+
+	// HTTPSFields are all of the https checkboxes, in page order
+	HTTPSFields []HTTPSCheckbox
+
+	// FieldNames are the names of all of the page's input and select fields,
+	// in page order
+	FieldNames []string
+}
+
+// InspectHTTPSettings reads the http settings page and returns the fields
+// found on it, to debug SetActiveCert on models whose page differs. Unlike
+// SetActiveCert, it doesn't fail if the cert dropdown isn't found
+// (CertSelectField is empty instead).
+func (p *printer) InspectHTTPSettings() (*HTTPSettingsInspection, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.inspectHttpSettings(ctx)
+}
+
+// inspectHttpSettings performs InspectHTTPSettings using ctx
+func (p *printer) inspectHttpSettings(ctx context.Context) (*HTTPSettingsInspection, error) {
+	bodyBytes, err := p.getHttpSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logForm(ctx, urlHttpCertServerSettings, bodyBytes)
+
+	inspection := &HTTPSettingsInspection{
+		HTTPSFields: []HTTPSCheckbox{},
+		FieldNames:  parseFormFieldOrder(bodyBytes),
+	}
+
+	// the checkboxes are found even if the dropdown isn't
+	fields, err := parseHttpSettingsFormFields(bodyBytes)
+	if err != nil {
+		fields = httpSettingsFormFields{httpsFields: parseHttpsCheckboxes(bodyBytes)}
+	}
+
+	inspection.CertSelectField = fields.certSelectField
+	if fields.certSelectField != "" {
+		inspection.SelectedCertID, _ = parseSelectedOption(bodyBytes, fields.certSelectField)
+	}
+
+	for _, checkbox := range fields.httpsFields {
+		inspection.HTTPSFields = append(inspection.HTTPSFields, HTTPSCheckbox{
+			Name:    checkbox.name,
+			Label:   checkbox.label,
+			Checked: checkbox.checked,
+			Found:   true,
+		})
+	}
+
+	inspection.HTTPSWebField = fields.inspectServiceCheckbox(ServiceWebUI)
+	inspection.HTTPSIPPField = fields.inspectServiceCheckbox(ServiceIPP)
+
+	return inspection, nil
+}
+
+// inspectServiceCheckbox returns the https checkbox of the service, as
+// SetActiveCert would submit it
+func (fields httpSettingsFormFields) inspectServiceCheckbox(service Service) HTTPSCheckbox {
+	name := fields.serviceCheckboxName(service)
+	for _, checkbox := range fields.httpsFields {
+		if checkbox.name == name {
+			return HTTPSCheckbox{
+				Name:    checkbox.name,
+				Label:   checkbox.label,
+				Checked: checkbox.checked,
+				Found:   true,
+			}
+		}
+	}
+
+	return HTTPSCheckbox{Name: name}
+}
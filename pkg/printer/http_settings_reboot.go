@@ -0,0 +1,67 @@
+package printer
+
+import (
+	"context"
+	"maps"
+	"net/url"
+)
+
+// stagedHttpSettings is an http settings change that was submitted without
+// confirming it (which would restart the printer). It is only kept in the
+// printer value's memory, not persisted, so it doesn't outlive the process.
+type stagedHttpSettings struct {
+	data url.Values
+	mode string
+}
+
+// RebootPrinter restarts the printer by submitting the http settings
+// confirmation. If a cert change was staged by SetActiveCertWithOptions (with
+// SkipReboot) on this printer value, it is applied; otherwise (including a
+// change staged by another process) the current http settings are
+// resubmitted unchanged. It returns once the restart is confirmed, without
+// waiting for the printer to come back (see WaitForOnline).
+func (p *printer) RebootPrinter() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.rebootPrinter(p.dryRunContext(ctx, "reboot printer", ""))
+}
+
+// rebootPrinter performs RebootPrinter using ctx
+func (p *printer) rebootPrinter(ctx context.Context) error {
+	// GET http settings (for a fresh CSRFToken)
+	bodyBytes, err := p.getHttpSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+	if err != nil {
+		return err
+	}
+
+	// staged change, or the current settings
+	// 4 == do NOT activate other secure protos
+	data, mode := parseFormValues(bodyBytes), "4"
+	if p.stagedHttpSettings != nil {
+		data, mode = maps.Clone(p.stagedHttpSettings.data), p.stagedHttpSettings.mode
+	}
+	data.Set(csrfToken.name, csrfToken.value)
+
+	confirmBody, err := p.postHttpSettingsForm(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	err = p.confirmHttpSettings(ctx, confirmBody, mode)
+	if err != nil {
+		return err
+	}
+
+	p.stagedHttpSettings = nil
+
+	return nil
+}
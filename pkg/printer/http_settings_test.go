@@ -0,0 +1,108 @@
+package printer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseHttpSettingsFormFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		page          string
+		wantSelect    string
+		wantEnable    []string
+		wantFallbacks []string
+	}{
+		{
+			name: "default names",
+			page: `<select name="B800"><option value="1">TLS 1.2</option></select>
+<select name="B903"><option value="0">Preset</option></select>
+<input type="checkbox" id="B8aa" name="B8aa" value="1"/><label for="B8aa">Web Services</label>
+<input type="checkbox" id="B86c" name="B86c" value="1"/><label for="B86c">Web Based Management</label>
+<input type="checkbox" id="B87e" name="B87e" value="1"/><label for="B87e">IPP</label>`,
+			wantSelect: "B903",
+			wantEnable: []string{"B86c", "B87e"},
+		},
+		{
+			name: "by label",
+			page: `<label for="B800">Protocol</label><select id="B800" name="B800"><option value="1">TLS 1.2</option></select>
+<label for="B9aa">Select the Certificate</label><select id="B9aa" name="B9aa"><option value="0">Preset</option></select>
+<input type="checkbox" id="B9b1" name="B9b1" value="1"/><label for="B9b1">IPP</label>
+<input type="checkbox" id="B9b2" name="B9b2" value="1"/><label for="B9b2">Web Based Management</label>`,
+			wantSelect:    "B9aa",
+			wantEnable:    []string{"B9b2", "B9b1"},
+			wantFallbacks: []string{"cert select B9aa", "webui https checkbox B9b2 (by label)", "ipp https checkbox B9b1 (by label)"},
+		},
+		{
+			name: "by position",
+			page: `Certificate <select name="B9aa"><option value="0">Preset</option></select>
+<input type="checkbox" name="B9b1" value="1"/>
+<input type="checkbox" name="B9b2" value="1"/>
+<input type="checkbox" name="B9b3" value="1"/>`,
+			wantSelect:    "B9aa",
+			wantEnable:    []string{"B9b1", "B9b2"},
+			wantFallbacks: []string{"cert select B9aa", "webui https checkbox B9b1 (by position)", "ipp https checkbox B9b2 (by position)"},
+		},
+		{
+			name:       "no checkboxes",
+			page:       `<select name="B903"><option value="0">Preset</option></select>`,
+			wantSelect: "B903",
+			wantEnable: []string{defaultHttpsWebField, defaultHttpsIppField},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := parseHttpSettingsFormFields([]byte(tt.page))
+			if err != nil {
+				t.Fatalf("parseHttpSettingsFormFields() error = %v", err)
+			}
+
+			if fields.certSelectField != tt.wantSelect {
+				t.Errorf("cert select = %q, want %q", fields.certSelectField, tt.wantSelect)
+			}
+
+			enable, err := fields.httpsFieldsToEnable(nil)
+			if err != nil {
+				t.Fatalf("httpsFieldsToEnable() error = %v", err)
+			}
+			names := []string{}
+			for _, checkbox := range enable {
+				names = append(names, checkbox.name)
+			}
+			if !slices.Equal(names, tt.wantEnable) {
+				t.Errorf("httpsFieldsToEnable() = %q, want %q", names, tt.wantEnable)
+			}
+
+			if len(fields.fallbacks) != len(tt.wantFallbacks) {
+				t.Fatalf("fallbacks = %q, want %q", fields.fallbacks, tt.wantFallbacks)
+			}
+			for i, want := range tt.wantFallbacks {
+				if !strings.HasPrefix(fields.fallbacks[i], want) {
+					t.Errorf("fallbacks[%d] = %q, want prefix %q", i, fields.fallbacks[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCertSelectName(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"labeled", `<label for="B1">EAP Method</label><select id="B1" name="B1"></select><label for="B2">Client Certificate</label><select id="B2" name="B2"></select>`, "B2"},
+		{"preceding text", `Method <select name="B1"></select> Certificate <select name="B2"></select>`, "B2"},
+		{"none labeled", `<select name="B1"></select><select name="B2"></select>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCertSelectName([]byte(tt.page)); got != tt.want {
+				t.Errorf("parseCertSelectName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
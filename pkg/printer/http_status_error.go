@@ -0,0 +1,37 @@
+package printer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPStatusError is returned when the printer responds with an unexpected
+// status code. Use errors.As to inspect the status code (e.g. to tell an
+// authentication failure from a server error).
+type HTTPStatusError struct {
+	// StatusCode is the status code of the response
+	StatusCode int
+	// URL is the url of the request
+	URL string
+	// Op describes the request (e.g. "get of http settings page")
+	Op string
+}
+
+// Error implements error
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("printer: %s failed (status code %d)", e.Op, e.StatusCode)
+}
+
+// newHTTPStatusError returns an HTTPStatusError for resp
+func newHTTPStatusError(op string, resp *http.Response) *HTTPStatusError {
+	url := ""
+	if resp.Request != nil && resp.Request.URL != nil {
+		url = resp.Request.URL.String()
+	}
+
+	return &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		URL:        url,
+		Op:         op,
+	}
+}
@@ -0,0 +1,92 @@
+package printer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrHTTPSRequired is returned when http is used but the printer only serves
+// its web ui over https
+var ErrHTTPSRequired = errors.New("printer: printer requires https for its web ui (do not use http)")
+
+// WithHTTPSUpgrade makes NewPrinter switch to https (instead of failing with
+// ErrHTTPSRequired) if http was requested but the printer requires https. The
+// printer's cert must then be trusted.
+func WithHTTPSUpgrade() Option {
+	return func(p *printer) {
+		p.httpsUpgrade = true
+	}
+}
+
+// checkRespForHTTPSRedirect returns ErrHTTPSRequired if resp is a redirect of
+// an http request to https
+func checkRespForHTTPSRedirect(resp *http.Response) error {
+	if resp.Request == nil || resp.Request.URL.Scheme != "http" {
+		return nil
+	}
+
+	if !isRedirect(resp.StatusCode) {
+		return nil
+	}
+
+	loc, err := resp.Location()
+	if err != nil || !strings.EqualFold(loc.Scheme, "https") {
+		return nil
+	}
+
+	return ErrHTTPSRequired
+}
+
+// RequiresHTTPS checks whether the printer only serves its web ui over https,
+// i.e. http requests are redirected to https or http is not served at all
+// while https is.
+func (p *printer) RequiresHTTPS() (bool, error) {
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	// get url & set path (always http)
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return false, err
+	}
+	u.Scheme = "http"
+	u.Path = p.urlPath(urlLogin)
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		// http not served at all; if https is, https is required
+		hostname, addr, addrErr := p.httpsAddr()
+		if addrErr != nil {
+			return false, addrErr
+		}
+
+		dialer := &tls.Dialer{
+			Config: &tls.Config{
+				InsecureSkipVerify: true,
+				ServerName:         hostname,
+			},
+		}
+
+		conn, tlsErr := dialer.DialContext(ctx, "tcp", p.transport.dialAddr(addr))
+		if tlsErr != nil {
+			return false, fmt.Errorf("printer: neither http (%s) nor https (%s) is reachable", err, tlsErr)
+		}
+		_ = conn.Close()
+
+		return true, nil
+	}
+	defer resp.Body.Close()
+
+	return errors.Is(checkRespForHTTPSRedirect(resp), ErrHTTPSRequired), nil
+}
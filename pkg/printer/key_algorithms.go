@@ -0,0 +1,87 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the Create CSR page lists the key types the printer supports
+const urlCertCreateCSR = "/net/security/certificate/csr.html"
+
+// e.g. `RSA 2048bit` or `ECDSA 256bit` (the text of a key type option)
+var regexKeyAlgo = regexp.MustCompile(`(?i)\b(RSA|ECDSA|EC)\b\D*(\d+)`)
+
+// KeyAlgo is a key algorithm and size that the printer supports
+type KeyAlgo struct {
+	// Algorithm is "RSA" or "ECDSA"
+	Algorithm string
+	// Bits is the key size (RSA) or curve size (ECDSA)
+	Bits int
+}
+
+// String returns the algorithm and size, e.g. `RSA-2048`
+func (algo KeyAlgo) String() string {
+	return fmt.Sprintf("%s-%d", algo.Algorithm, algo.Bits)
+}
+
+// parseKeyAlgorithms parses the key type options of the Create CSR page
+func parseKeyAlgorithms(bodyBytes []byte) []KeyAlgo {
+	algos := []KeyAlgo{}
+
+	for _, sel := range parseSelects(bodyBytes) {
+		for _, option := range sel.options {
+			caps := regexKeyAlgo.FindStringSubmatch(option.text(bodyBytes))
+			if len(caps) != 3 {
+				continue
+			}
+
+			bits, err := strconv.Atoi(caps[2])
+			if err != nil {
+				continue
+			}
+
+			algo := strings.ToUpper(caps[1])
+			if algo == "EC" {
+				algo = "ECDSA"
+			}
+
+			algos = append(algos, KeyAlgo{
+				Algorithm: algo,
+				Bits:      bits,
+			})
+		}
+
+		// key type options are all in one select
+		if len(algos) > 0 {
+			break
+		}
+	}
+
+	return algos
+}
+
+// SupportedKeyAlgorithms returns the key algorithms and sizes that the printer
+// supports, as listed on its Create CSR page. If the printer doesn't have the
+// page, ErrUnsupported is returned.
+func (p *printer) SupportedKeyAlgorithms() ([]KeyAlgo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	bodyBytes, err := p.getOptionalPage(ctx, urlCertCreateCSR)
+	if err != nil {
+		return nil, err
+	}
+
+	algos := parseKeyAlgorithms(bodyBytes)
+	if len(algos) == 0 {
+		return nil, fmt.Errorf("%w (no key types listed on create csr page)", ErrUnsupported)
+	}
+
+	return algos, nil
+}
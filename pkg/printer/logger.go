@@ -0,0 +1,75 @@
+package printer
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// WithLogger makes the printer emit debug logs of each step of its operations
+// (the pages requested, response status codes, and the form fields found on
+// pages) to l. Passwords, CSRFTokens, and key material are never logged (only
+// names and lengths). By default nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(p *printer) {
+		p.logger = l
+	}
+}
+
+// logForm logs the names of the form fields found on the page at path, and
+// the length of its CSRFToken (0 if none)
+func (p *printer) logForm(ctx context.Context, path string, bodyBytes []byte) {
+	if !p.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	values := parseFormValues(bodyBytes)
+	csrfToken, _ := parseBodyForCSRFToken(bodyBytes)
+	attrs := []slog.Attr{
+		slog.String("path", path),
+		slog.Any("fields", slices.Sorted(maps.Keys(values))),
+		slog.Int("file_inputs", len(parseFileInputs(bodyBytes))),
+		slog.Int("csrf_token_len", len(csrfToken.value)),
+	}
+	attrs = p.transport.appendCorrelationID(attrs)
+
+	p.logger.LogAttrs(ctx, slog.LevelDebug, "printer: parsed form", attrs...)
+}
+
+// appendCorrelationID returns attrs with the ID set by WithCorrelationID (if
+// any), so an operation's logs can be matched to the printer's requests
+func (trans *printerTransport) appendCorrelationID(attrs []slog.Attr) []slog.Attr {
+	if trans.correlationID == "" {
+		return attrs
+	}
+
+	return append(attrs, slog.String("correlation_id", trans.correlationID))
+}
+
+// logRoundTrip logs a request and its result
+func (trans *printerTransport) logRoundTrip(req *http.Request, resp *http.Response, err error, start time.Time) {
+	ctx := req.Context()
+	if !trans.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Redacted()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if location := resp.Header.Get("Location"); location != "" {
+			attrs = append(attrs, slog.String("location", location))
+		}
+	}
+	attrs = trans.appendCorrelationID(attrs)
+
+	trans.logger.LogAttrs(ctx, slog.LevelDebug, "printer: request", attrs...)
+}
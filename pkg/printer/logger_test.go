@@ -0,0 +1,44 @@
+package printer
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogCorrelationID(t *testing.T) {
+	m := newMockPrinter(t, "1")
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p, err := New(m.URL, WithLogger(logger), WithCorrelationID("run-42"), WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	keyPem, certPem := testECKeyCert(t, "printer.example.com")
+	_, err = p.UploadNewCert(keyPem, certPem)
+	if err != nil {
+		t.Fatalf("UploadNewCert() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	for _, msg := range []string{`msg="printer: request"`, `msg="printer: parsed form"`} {
+		found := false
+		for _, line := range lines {
+			if !strings.Contains(line, msg) {
+				continue
+			}
+			found = true
+			if !strings.Contains(line, "correlation_id=run-42") {
+				t.Errorf("log line has no correlation_id: %s", line)
+			}
+		}
+		if !found {
+			t.Errorf("no %s log lines", msg)
+		}
+	}
+}
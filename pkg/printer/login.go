@@ -1,11 +1,12 @@
 package printer
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
 )
 
@@ -20,36 +21,62 @@ var (
 // from the HTML login form
 func parsePasswordFieldName(bodyBytes []byte) (fieldName string, err error) {
 	// Look for input elements with type="password"
-	// This regex handles both orders: type first or name first
-	// e.g. <input type="password" name="Baf9" ... /> or <input name="Baf9" type="password" ... />
-	regex := regexp.MustCompile(`<input[^>]+(?:type="password"[^>]+name="([^"]+)"[^>]*|name="([^"]+)"[^>]+type="password"[^>]*)>`)
-	caps := regex.FindSubmatch(bodyBytes)
+	// e.g. <input type="password" name="Baf9" ... /> or <input name='Baf9' type=password ... />
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
+		if strings.EqualFold(attrs["type"], "password") && attrs["name"] != "" {
+			return attrs["name"], nil
+		}
+	}
 
 	// error if didn't find what was expected
-	if len(caps) < 2 {
-		return "", errPasswordFieldNotFound
-	}
+	return "", errPasswordFieldNotFound
+}
+
+// isLoginPage returns true if the html response input is the login page
+func isLoginPage(bodyBytes []byte) bool {
+	hasPassword := false
+	for _, input := range parseElements(bodyBytes, "input") {
+		attrs := input.attrs
 
-	// return the non-empty capture group (either caps[1] or caps[2])
-	if len(caps[1]) > 0 {
-		return string(caps[1]), nil
+		// login form posts the page to return to
+		if attrs["name"] == "loginurl" {
+			return true
+		}
+
+		if strings.EqualFold(attrs["type"], "password") {
+			hasPassword = true
+		}
 	}
-	return string(caps[2]), nil
+
+	// other forms with a password field (e.g. import) have a CSRFToken
+	return hasPassword && !bytes.Contains(bodyBytes, []byte("CSRFToken"))
 }
 
 // login performs the login command against the remote printer. it is
 // used internally as part of the printer creation process to ensure
 // credentials are valid
-func (p *printer) login(password string) error {
+func (p *printer) login(ctx context.Context, password string) error {
+	p.loginMu.Lock()
+	defer p.loginMu.Unlock()
+
+	return p.loginLocked(ctx, password)
+}
+
+// loginLocked performs login; loginMu must be held
+func (p *printer) loginLocked(ctx context.Context, password string) error {
+	// the login's own requests must not trigger a re-login
+	ctx = context.WithValue(ctx, noReloginKey{}, true)
+
 	// get url & set path
 	u, err := url.ParseRequestURI(p.baseUrl)
 	if err != nil {
 		return err
 	}
-	u.Path = urlLogin
+	u.Path = p.urlPath(urlLogin)
 
 	// first, fetch the login page to discover the password field name
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -60,12 +87,26 @@ func (p *printer) login(password string) error {
 	}
 	defer resp.Body.Close()
 
+	// redirected to https?
+	err = checkRespForHTTPSRedirect(resp)
+	if err != nil {
+		return err
+	}
+
 	// read the login page HTML
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
 
+	// printer in maintenance mode?
+	err = checkBodyForMaintenance(bodyBytes)
+	if err != nil {
+		return err
+	}
+
+	p.logForm(ctx, urlLogin, bodyBytes)
+
 	// parse the password field name from the HTML
 	passwordFieldName, err := parsePasswordFieldName(bodyBytes)
 	if err != nil {
@@ -78,7 +119,7 @@ func (p *printer) login(password string) error {
 	data.Set("loginurl", urlLogin)
 
 	// make and do login request
-	req, err = http.NewRequest(http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
@@ -108,6 +149,7 @@ func (p *printer) login(password string) error {
 
 	// set cookies in jar
 	p.httpClient.Jar.SetCookies(u, resp.Cookies())
+	p.logins.Add(1)
 
 	return nil
 }
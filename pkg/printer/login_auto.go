@@ -0,0 +1,179 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var errNoCredentials = errors.New("printer: login required but no password was provided")
+
+// ErrSessionExpired is returned when the printer's session ended during an
+// operation and it couldn't be logged in again (e.g. no password was provided)
+var ErrSessionExpired = errors.New("printer: session expired")
+
+// sessionCheckKey marks a context whose requests report a lost session as
+// ErrSessionExpired (instead of logging in again and retrying the request),
+// so the whole operation can be retried with fresh CSRFTokens
+type sessionCheckKey struct{}
+
+// noReloginKey marks a context whose requests must not trigger a re-login
+// (i.e. the login itself)
+type noReloginKey struct{}
+
+// WithPassword sets the password used to log in to the printer. With New,
+// the printer logs in automatically when the web UI requires it. The
+// password is also used to log in again if the session ends mid-operation.
+func WithPassword(password string) Option {
+	return func(p *printer) {
+		p.password = password
+	}
+}
+
+// Login logs in to the printer with the specified password, which is then
+// used to log in again if the session ends mid-operation
+func (p *printer) Login(password string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	err := p.login(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	p.password = password
+
+	return nil
+}
+
+// relogin logs in again with the printer's password. it is called by the
+// transport when a response shows the session isn't logged in, with the
+// number of logins when the request was sent.
+func (p *printer) relogin(ctx context.Context, logins uint64) error {
+	if p.password == "" {
+		return errNoCredentials
+	}
+
+	return p.loginSince(ctx, p.password, logins)
+}
+
+// loginSince logs in, unless there has been a login since the specified
+// number of logins (e.g. by another operation that also found the session
+// ended, whose new session logging in again would end)
+func (p *printer) loginSince(ctx context.Context, password string, logins uint64) error {
+	p.loginMu.Lock()
+	defer p.loginMu.Unlock()
+
+	if p.logins.Load() != logins {
+		return nil
+	}
+
+	return p.loginLocked(ctx, password)
+}
+
+// isLoginRequired returns true if resp shows the request wasn't allowed
+// because the session isn't logged in (401, a redirect to the login page, or
+// 403 to a request that had no auth cookie; a 403 to a logged in request is
+// some other refusal, e.g. a rejected CSRFToken)
+func isLoginRequired(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		if resp.Request == nil {
+			return false
+		}
+
+		_, err := resp.Request.Cookie("AuthCookie")
+		return errors.Is(err, http.ErrNoCookie)
+	}
+
+	if !isRedirect(resp.StatusCode) {
+		return false
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return false
+	}
+
+	return strings.HasSuffix(location.Path, urlLogin) || strings.Contains(strings.ToLower(location.Path), "login")
+}
+
+// roundTripWithRelogin performs the request and, if the response shows the
+// session isn't logged in, logs in again and retries the request once. only
+// GET and HEAD requests are retried; a form would be sent again with the old
+// session's CSRFToken, so ErrSessionExpired is returned for it instead and
+// withSessionRetry starts the op over.
+func (trans *printerTransport) roundTripWithRelogin(req *http.Request) (*http.Response, error) {
+	logins := uint64(0)
+	if trans.logins != nil {
+		logins = trans.logins()
+	}
+
+	resp, err := trans.roundTripWithRetries(req)
+	if err != nil || trans.relogin == nil || req.Context().Value(noReloginKey{}) != nil || !isLoginRequired(resp) {
+		return resp, err
+	}
+
+	// operation will retry itself, or not safe to send again?
+	if req.Context().Value(sessionCheckKey{}) == true || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		_ = resp.Body.Close()
+		return nil, ErrSessionExpired
+	}
+
+	// discard response
+	_ = resp.Body.Close()
+
+	retryReq := req.Clone(req.Context())
+
+	err = trans.relogin(req.Context(), logins)
+	if err != nil {
+		return nil, fmt.Errorf("printer: session not logged in and login failed (%w)", err)
+	}
+
+	// use the new session's cookies
+	if trans.jar != nil {
+		retryReq.Header.Del("Cookie")
+		for _, c := range trans.jar.Cookies(retryReq.URL) {
+			retryReq.AddCookie(c)
+		}
+	}
+
+	return trans.roundTripWithRetries(retryReq)
+}
+
+// withoutSessionCheck returns ctx without the session check of
+// withSessionRetry, for the requests an op makes after its form was accepted
+// (the op must not start over then, or the form would be submitted twice).
+// if the session ends during them, the requests log in again themselves.
+func withoutSessionCheck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionCheckKey{}, false)
+}
+
+// withSessionRetry performs op and, if the session ended during it, logs in
+// again and performs op once more. a retried op starts over, so it gets fresh
+// CSRFTokens (a form posted with the old session's token would fail). if
+// there is no password to log in with, ErrSessionExpired is returned.
+func (p *printer) withSessionRetry(ctx context.Context, op func(ctx context.Context) error) error {
+	ctx = context.WithValue(ctx, sessionCheckKey{}, true)
+
+	logins := p.logins.Load()
+	err := op(ctx)
+	if !errors.Is(err, ErrSessionExpired) || p.password == "" {
+		return err
+	}
+
+	err = p.loginSince(ctx, p.password, logins)
+	if err != nil {
+		return fmt.Errorf("%w (login failed: %s)", ErrSessionExpired, err)
+	}
+
+	return op(ctx)
+}
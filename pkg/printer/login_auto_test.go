@@ -0,0 +1,95 @@
+package printer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsLoginRequired(t *testing.T) {
+	loggedIn := &http.Request{Header: http.Header{"Cookie": []string{"AuthCookie=abc"}}}
+	loggedOut := &http.Request{Header: http.Header{}}
+
+	tests := []struct {
+		name     string
+		status   int
+		location string
+		req      *http.Request
+		want     bool
+	}{
+		{"ok", http.StatusOK, "", loggedIn, false},
+		{"unauthorized", http.StatusUnauthorized, "", loggedIn, true},
+		{"forbidden without auth cookie", http.StatusForbidden, "", loggedOut, true},
+		{"forbidden with auth cookie", http.StatusForbidden, "", loggedIn, false},
+		{"redirect to login", http.StatusFound, "/general/status.html", loggedIn, true},
+		{"redirect elsewhere", http.StatusFound, "/net/net/certificate/http.html", loggedIn, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.URL = &url.URL{Scheme: "http", Host: "printer", Path: "/"}
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Request: tt.req}
+			if tt.location != "" {
+				resp.Header.Set("Location", tt.location)
+			}
+
+			if got := isLoginRequired(resp); got != tt.want {
+				t.Errorf("isLoginRequired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentReadersLogInOnce(t *testing.T) {
+	var logins atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == urlLogin && r.Method == http.MethodPost:
+			// slow, so the other reader finds the session ended meanwhile
+			time.Sleep(50 * time.Millisecond)
+			n := logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "AuthCookie", Value: strconv.Itoa(int(n)), Path: "/"})
+
+		case r.URL.Path == urlLogin:
+			_, _ = w.Write([]byte(`<form><input type="password" name="B123"><input type="hidden" name="loginurl" value="/"></form>`))
+
+		case r.URL.Path == urlCertList:
+			// only the latest login's session is valid
+			c, err := r.Cookie("AuthCookie")
+			if err != nil || c.Value != strconv.Itoa(int(logins.Load())) {
+				http.Redirect(w, r, urlLogin, http.StatusFound)
+				return
+			}
+			_, _ = w.Write([]byte(certListPage1))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL, WithHTTPClient(srv.Client()), WithPassword("pw"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			_, err := p.ListCerts()
+			if err != nil {
+				t.Errorf("ListCerts() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := logins.Load(); got != 1 {
+		t.Errorf("logged in %d times, want 1", got)
+	}
+}
@@ -0,0 +1,83 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrDeviceInMaintenance is returned when the printer's web UI responds with
+// its maintenance page (e.g. during a firmware update or while in an error
+// state) instead of the expected page. The operation can be retried later.
+var ErrDeviceInMaintenance = errors.New("printer: device is in maintenance mode")
+
+var (
+	// e.g. `<title>Maintenance Mode</title>` or `<h1>Updating Firmware</h1>`
+	regexTitleOrHeading     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>|<h[1-6][^>]*>(.*?)</h[1-6]>`)
+	regexMaintenanceHeading = regexp.MustCompile(`(?i)maintenance\s+mode|updating\s+firmware|firmware\s+(?:is\s+)?(?:being\s+|now\s+)?updating`)
+	// e.g. `Firmware is being updated. Please wait.` (explicit status wording,
+	// unlike e.g. a `Firmware Update` menu link on every page)
+	regexMaintenanceStatus = regexp.MustCompile(`(?i)firmware\s+is\s+(?:now\s+)?(?:being\s+)?updat(?:ed|ing)|now\s+updating|(?:is|in)\s+maintenance\s+mode`)
+	regexHtmlTags          = regexp.MustCompile(`(?s)<script.*?</script>|<style.*?</style>|<[^>]*>`)
+)
+
+// maxStatusTextLen limits how much of the maintenance page is included in
+// the returned error
+const maxStatusTextLen = 200
+
+// truncateStatusText shortens text to at most maxStatusTextLen bytes (on a
+// rune boundary, so a multi-byte character isn't split) and marks it as
+// truncated
+func truncateStatusText(text string) string {
+	if len(text) <= maxStatusTextLen {
+		return text
+	}
+
+	end := maxStatusTextLen
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	return text[:end] + "..."
+}
+
+// htmlToText strips tags from the html input and collapses whitespace
+func htmlToText(bodyBytes []byte) string {
+	text := regexHtmlTags.ReplaceAllString(string(bodyBytes), " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// isMaintenancePage returns true if the html response input's title or a
+// heading names maintenance mode, or its visible text states it (matching
+// only the visible text, not e.g. scripts or menu links)
+func isMaintenancePage(bodyBytes []byte) bool {
+	for _, match := range regexTitleOrHeading.FindAllSubmatch(bodyBytes, -1) {
+		heading := htmlToText([]byte(string(match[1]) + string(match[2])))
+		if regexMaintenanceHeading.MatchString(heading) {
+			return true
+		}
+	}
+
+	return regexMaintenanceStatus.MatchString(htmlToText(bodyBytes))
+}
+
+// checkBodyForMaintenance returns ErrDeviceInMaintenance, wrapped with any
+// status text on the page, if the html response input is the printer's
+// maintenance page
+func checkBodyForMaintenance(bodyBytes []byte) error {
+	if !isMaintenancePage(bodyBytes) {
+		return nil
+	}
+
+	// include the page's text to aid diagnosis
+	statusText := truncateStatusText(htmlToText(bodyBytes))
+
+	if statusText == "" {
+		return ErrDeviceInMaintenance
+	}
+
+	return fmt.Errorf("%w (%s)", ErrDeviceInMaintenance, statusText)
+}
@@ -0,0 +1,78 @@
+package printer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCheckBodyForMaintenance(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{
+			name: "maintenance title",
+			body: `<html><head><title>Maintenance Mode</title></head><body>Please wait.</body></html>`,
+			want: true,
+		},
+		{
+			name: "updating heading",
+			body: `<html><body><h1>Updating Firmware</h1><p>Do not turn off the machine.</p></body></html>`,
+			want: true,
+		},
+		{
+			name: "status wording",
+			body: `<html><body><p>Firmware is being updated. Please wait.</p></body></html>`,
+			want: true,
+		},
+		{
+			name: "in maintenance mode wording",
+			body: `<html><body><p>The machine is in Maintenance Mode.</p></body></html>`,
+			want: true,
+		},
+		{
+			name: "firmware update menu link",
+			body: `<html><head><title>Import Certificate</title></head><body><ul><li><a href="/admin/firmware.html">Firmware Update</a></li></ul><form><input type="hidden" name="pageid" value="390"/></form></body></html>`,
+			want: false,
+		},
+		{
+			name: "firmware update menu heading",
+			body: `<html><body><h3>Firmware Update</h3><a href="/admin/firmware.html">Check for new firmware</a></body></html>`,
+			want: false,
+		},
+		{
+			name: "wording in a script",
+			body: `<html><body><script>var msg = "Firmware is being updated";</script><form></form></body></html>`,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBodyForMaintenance([]byte(tt.body))
+			if got := errors.Is(err, ErrDeviceInMaintenance); got != tt.want {
+				t.Errorf("checkBodyForMaintenance() error = %v, want maintenance %t", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateStatusText(t *testing.T) {
+	short := "Maintenance Mode"
+	if got := truncateStatusText(short); got != short {
+		t.Errorf("truncateStatusText() = %q, want %q", got, short)
+	}
+
+	// a 3 byte character straddles the limit
+	long := strings.Repeat("a", maxStatusTextLen-1) + "日本"
+	got := truncateStatusText(long)
+	if !utf8.ValidString(got) {
+		t.Errorf("truncateStatusText() = %q, not valid utf-8", got)
+	}
+	if want := strings.Repeat("a", maxStatusTextLen-1) + "..."; got != want {
+		t.Errorf("truncateStatusText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package mdns
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// browseServices are the services Brother printers advertise that identify
+// the device (the ipp TXT record includes the model)
+var browseServices = []string{
+	"_ipp._tcp.local",
+	"_http._tcp.local",
+}
+
+// e.g. `Brother HL-L2350DW series` or `(Brother HL-L2350DW series)`
+var regexBrotherModel = regexp.MustCompile(`(?i)brother\s+([^()]+?)(?:\s+series)?\s*\)?$`)
+
+// Discovered is a Brother printer found on the local network
+type Discovered struct {
+	// Name is the Bonjour service instance name (e.g.
+	// `Brother HL-L2350DW series._ipp._tcp.local`)
+	Name string
+	// Hostname is the device's .local hostname
+	Hostname string
+	// Addr is the device's current IP address
+	Addr string
+	// Model is the device's model (e.g. `HL-L2350DW`), if advertised
+	Model string
+}
+
+// brotherModel returns the model from an instance name or TXT record and
+// whether the device is a Brother
+func brotherModel(instance string, txt map[string]string) (string, bool) {
+	isBrother := strings.EqualFold(txt["usb_mfg"], "brother")
+
+	// model from the most specific field available
+	for _, s := range []string{txt["ty"], txt["product"], txt["usb_mdl"], instance} {
+		if s == "" {
+			continue
+		}
+
+		caps := regexBrotherModel.FindStringSubmatch(s)
+		if len(caps) == 2 {
+			return strings.TrimSpace(caps[1]), true
+		}
+		if isBrother {
+			return strings.Trim(s, "() "), true
+		}
+	}
+
+	return "", isBrother
+}
+
+// DiscoverPrinters browses the local network via mDNS for timeout and returns
+// the Brother printers that responded, one per device. If mDNS isn't
+// available, ErrUnavailable is returned.
+func DiscoverPrinters(ctx context.Context, timeout time.Duration) ([]Discovered, error) {
+	browseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// device info, by hostname
+	found := make(map[string]*Discovered)
+	// instances that didn't include their SRV record
+	unresolved := make(map[string]string)
+
+	for _, service := range browseServices {
+		msg, err := makeQuery(service, typePTR)
+		if err != nil {
+			return nil, err
+		}
+
+		// responses are collected until the timeout, so split the time
+		// between the services
+		serviceCtx, serviceCancel := context.WithTimeout(browseCtx, timeout/time.Duration(len(browseServices)))
+		err = query(serviceCtx, msg, func(records []record) bool {
+			for _, r := range records {
+				if r.rtype != typePTR || !equalNames(r.name, service) {
+					continue
+				}
+				instance, err := r.ptrName()
+				if err != nil {
+					continue
+				}
+
+				// instance details are usually in the additional records
+				txt := map[string]string{}
+				hostname := ""
+				for _, rr := range records {
+					if !equalNames(rr.name, instance) {
+						continue
+					}
+					switch rr.rtype {
+					case typeTXT:
+						txt = rr.txtValues()
+					case typeSRV:
+						hostname, _, _ = rr.srvTarget()
+					}
+				}
+
+				model, isBrother := brotherModel(strings.SplitN(instance, "._", 2)[0], txt)
+				if !isBrother {
+					continue
+				}
+
+				if hostname == "" {
+					unresolved[instance] = model
+					continue
+				}
+
+				addDiscovered(found, Discovered{
+					Name:     instance,
+					Hostname: hostname,
+					Addr:     addrFromRecords(records, hostname),
+					Model:    model,
+				})
+			}
+
+			// keep listening for other devices
+			return false
+		})
+		serviceCancel()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+	}
+
+	// resolve anything that was missing details (best effort)
+	resolveCtx, resolveCancel := context.WithTimeout(ctx, queryTimeout)
+	defer resolveCancel()
+
+	for instance, model := range unresolved {
+		res, err := resolveService(resolveCtx, instance)
+		if err != nil {
+			continue
+		}
+
+		addDiscovered(found, Discovered{
+			Name:     instance,
+			Hostname: res.Hostname,
+			Addr:     res.Addr,
+			Model:    model,
+		})
+	}
+	for _, d := range found {
+		if d.Addr != "" {
+			continue
+		}
+		addr, err := resolveHost(resolveCtx, d.Hostname)
+		if err == nil {
+			d.Addr = addr
+		}
+	}
+
+	// sorted for stable output
+	printers := []Discovered{}
+	for _, d := range found {
+		printers = append(printers, *d)
+	}
+	slices.SortFunc(printers, func(a, b Discovered) int {
+		return strings.Compare(strings.ToLower(a.Hostname), strings.ToLower(b.Hostname))
+	})
+
+	return printers, nil
+}
+
+// addDiscovered adds d to found, merging it with any entry for the same
+// device. the first service seen (ipp) provides the name.
+func addDiscovered(found map[string]*Discovered, d Discovered) {
+	key := strings.ToLower(strings.TrimSuffix(d.Hostname, "."))
+	d.Hostname = strings.TrimSuffix(d.Hostname, ".")
+
+	existing, ok := found[key]
+	if !ok {
+		found[key] = &d
+		return
+	}
+
+	if existing.Addr == "" {
+		existing.Addr = d.Addr
+	}
+	if existing.Model == "" {
+		existing.Model = d.Model
+	}
+}
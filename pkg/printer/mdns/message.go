@@ -0,0 +1,290 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// dns record types used by this package
+const (
+	typeA    uint16 = 1
+	typePTR  uint16 = 12
+	typeTXT  uint16 = 16
+	typeAAAA uint16 = 28
+	typeSRV  uint16 = 33
+
+	classIN uint16 = 1
+)
+
+var errMalformedMessage = errors.New("mdns: malformed dns message")
+
+// record is a resource record from a dns message
+type record struct {
+	name  string
+	rtype uint16
+	// msg and rdata offset are kept so names in rdata can be decompressed
+	msg      []byte
+	rdataOff int
+	rdataLen int
+}
+
+// rdata returns the record's raw data
+func (r record) rdata() []byte {
+	return r.msg[r.rdataOff : r.rdataOff+r.rdataLen]
+}
+
+// splitName splits a dns name into its labels. A backslash escapes the next
+// character (e.g. `Brother\ HL-L2350DW._http._tcp.local`) or starts a three
+// digit decimal escape (e.g. `\032`).
+func splitName(name string) ([]string, error) {
+	name = strings.TrimSuffix(name, ".")
+
+	labels := []string{}
+	label := []byte{}
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '\\':
+			if i+3 < len(name) && isDigits(name[i+1:i+4]) {
+				v, err := strconv.Atoi(name[i+1 : i+4])
+				if err != nil || v > 255 {
+					return nil, errors.New("mdns: invalid escape in name")
+				}
+				label = append(label, byte(v))
+				i += 3
+			} else if i+1 < len(name) {
+				label = append(label, name[i+1])
+				i++
+			}
+
+		case '.':
+			labels = append(labels, string(label))
+			label = []byte{}
+
+		default:
+			label = append(label, name[i])
+		}
+	}
+	labels = append(labels, string(label))
+
+	for _, l := range labels {
+		if len(l) == 0 || len(l) > 63 {
+			return nil, errors.New("mdns: invalid label length in name")
+		}
+	}
+
+	return labels, nil
+}
+
+// isDigits returns true if s is all decimal digits
+func isDigits(s string) bool {
+	for i := range s {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return len(s) > 0
+}
+
+// equalNames compares two dns names, ignoring case, escaping, and a trailing
+// dot
+func equalNames(a, b string) bool {
+	aLabels, err := splitName(a)
+	if err != nil {
+		return false
+	}
+	bLabels, err := splitName(b)
+	if err != nil || len(aLabels) != len(bLabels) {
+		return false
+	}
+
+	for i := range aLabels {
+		if !strings.EqualFold(aLabels[i], bLabels[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// makeQuery builds a dns query message for name with one question per type
+func makeQuery(name string, qtypes ...uint16) ([]byte, error) {
+	labels, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// header (id 0, no flags, question count)
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(qtypes)))
+
+	for _, qtype := range qtypes {
+		for _, l := range labels {
+			msg = append(msg, byte(len(l)))
+			msg = append(msg, l...)
+		}
+		msg = append(msg, 0)
+		msg = binary.BigEndian.AppendUint16(msg, qtype)
+		msg = binary.BigEndian.AppendUint16(msg, classIN)
+	}
+
+	return msg, nil
+}
+
+// readName reads a (possibly compressed) name at off in msg and returns it
+// along with the offset after the name
+func readName(msg []byte, off int) (string, int, error) {
+	labels := []string{}
+	end := -1
+
+	// limit pointer jumps to prevent loops
+	for jumps := 0; jumps < 64; {
+		if off >= len(msg) {
+			return "", 0, errMalformedMessage
+		}
+
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, "."), end, nil
+
+		case length&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, errMalformedMessage
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, errMalformedMessage
+			}
+			labels = append(labels, escapeLabel(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+
+	return "", 0, errMalformedMessage
+}
+
+// escapeLabel escapes dots and backslashes in a label so the joined name can
+// be split again
+func escapeLabel(l []byte) string {
+	s := string(l)
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, ".", `\.`)
+}
+
+// parseMessage returns all of the resource records in a dns response message
+func parseMessage(msg []byte) ([]record, error) {
+	if len(msg) < 12 {
+		return nil, errMalformedMessage
+	}
+
+	// must be a response
+	if msg[2]&0x80 == 0 {
+		return nil, nil
+	}
+
+	qdCount := int(binary.BigEndian.Uint16(msg[4:]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	// skip questions
+	off := 12
+	for range qdCount {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4
+	}
+
+	records := []record{}
+	for range rrCount {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next
+
+		if off+10 > len(msg) {
+			return nil, errMalformedMessage
+		}
+		rtype := binary.BigEndian.Uint16(msg[off:])
+		rdataLen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+
+		if off+rdataLen > len(msg) {
+			return nil, errMalformedMessage
+		}
+
+		records = append(records, record{
+			name:     name,
+			rtype:    rtype,
+			msg:      msg,
+			rdataOff: off,
+			rdataLen: rdataLen,
+		})
+		off += rdataLen
+	}
+
+	return records, nil
+}
+
+// srvTarget returns the target host and port of a SRV record
+func (r record) srvTarget() (string, int, error) {
+	if r.rtype != typeSRV || r.rdataLen < 7 {
+		return "", 0, errMalformedMessage
+	}
+
+	// priority, weight, port, target
+	port := int(binary.BigEndian.Uint16(r.msg[r.rdataOff+4:]))
+	target, _, err := readName(r.msg, r.rdataOff+6)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return target, port, nil
+}
+
+// ptrName returns the name a PTR record points to
+func (r record) ptrName() (string, error) {
+	if r.rtype != typePTR {
+		return "", errMalformedMessage
+	}
+
+	name, _, err := readName(r.msg, r.rdataOff)
+	return name, err
+}
+
+// txtValues returns the key=value strings of a TXT record. keys are lower
+// cased.
+func (r record) txtValues() map[string]string {
+	values := make(map[string]string)
+	if r.rtype != typeTXT {
+		return values
+	}
+
+	rdata := r.rdata()
+	for off := 0; off < len(rdata); {
+		length := int(rdata[off])
+		if off+1+length > len(rdata) {
+			break
+		}
+		key, value, _ := strings.Cut(string(rdata[off+1:off+1+length]), "=")
+		if key != "" {
+			values[strings.ToLower(key)] = value
+		}
+		off += 1 + length
+	}
+
+	return values
+}
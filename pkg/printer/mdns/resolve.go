@@ -0,0 +1,267 @@
+// Package mdns resolves and discovers Brother printers using multicast dns
+// (Bonjour). It is a separate package so the printer package has no mDNS
+// dependency.
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/gregtwallace/brother-cert/pkg/printer"
+)
+
+// queryTimeout is how long to wait for responses when ctx has no deadline
+const queryTimeout = 3 * time.Second
+
+var (
+	// ErrUnavailable is returned when multicast dns can't be used (e.g. no
+	// multicast capable network interface)
+	ErrUnavailable = errors.New("mdns: multicast dns unavailable")
+
+	// ErrNotFound is returned when nothing answered for the name
+	ErrNotFound = errors.New("mdns: name not found")
+)
+
+// mdnsGroup is the multicast dns group address
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Resolved is the result of resolving an mDNS name
+type Resolved struct {
+	// Hostname is the device's .local hostname
+	Hostname string
+	// Addr is the device's current IP address
+	Addr string
+	// Port is the port of the service (0 if a hostname was resolved)
+	Port int
+}
+
+// IsMDNSName returns true if name is a .local hostname (e.g. BRN3C2AF4.local)
+// or a Bonjour service instance name (e.g. `Brother HL-L2350DW._http._tcp.local`)
+func IsMDNSName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(name, ".")), ".local")
+}
+
+// isServiceName returns true if name is a service instance name
+func isServiceName(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "._tcp.") || strings.Contains(name, "._udp.")
+}
+
+// query sends a one-shot query and calls handle with the records of each
+// response until handle returns true or the wait is over. responders send
+// one-shot responses directly to the querier, so the multicast group doesn't
+// need to be joined.
+func query(ctx context.Context, msg []byte, handle func([]record) bool) error {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return fmt.Errorf("%w (%s)", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(queryTimeout)
+	}
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return err
+	}
+
+	// stop reading if ctx is canceled
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	_, err = conn.WriteTo(msg, mdnsGroup)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", ErrUnavailable, err)
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// timeout is the normal end of the wait (unless ctx was canceled)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		// ignore anything that isn't a valid response
+		records, err := parseMessage(append([]byte(nil), buf[:n]...))
+		if err != nil || len(records) == 0 {
+			continue
+		}
+
+		if handle(records) {
+			return nil
+		}
+	}
+}
+
+// addrFromRecords returns the first A (preferred) or AAAA address of host in
+// records
+func addrFromRecords(records []record, host string) string {
+	v6 := ""
+	for _, r := range records {
+		if !equalNames(r.name, host) {
+			continue
+		}
+
+		switch {
+		case r.rtype == typeA && r.rdataLen == 4:
+			return netip.AddrFrom4([4]byte(r.rdata())).String()
+
+		case r.rtype == typeAAAA && r.rdataLen == 16 && v6 == "":
+			addr := netip.AddrFrom16([16]byte(r.rdata()))
+			// link local addresses aren't usable without a zone
+			if !addr.IsLinkLocalUnicast() {
+				v6 = addr.String()
+			}
+		}
+	}
+
+	return v6
+}
+
+// resolveHost resolves a .local hostname to an address
+func resolveHost(ctx context.Context, host string) (string, error) {
+	msg, err := makeQuery(host, typeA, typeAAAA)
+	if err != nil {
+		return "", err
+	}
+
+	addr := ""
+	err = query(ctx, msg, func(records []record) bool {
+		addr = addrFromRecords(records, host)
+		return addr != ""
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if addr == "" {
+		return "", fmt.Errorf("%w (%s)", ErrNotFound, host)
+	}
+
+	return addr, nil
+}
+
+// resolveService resolves a service instance name to its host, port, and
+// address
+func resolveService(ctx context.Context, name string) (Resolved, error) {
+	msg, err := makeQuery(name, typeSRV)
+	if err != nil {
+		return Resolved{}, err
+	}
+
+	res := Resolved{}
+	err = query(ctx, msg, func(records []record) bool {
+		for _, r := range records {
+			if r.rtype != typeSRV || !equalNames(r.name, name) {
+				continue
+			}
+
+			target, port, err := r.srvTarget()
+			if err != nil {
+				continue
+			}
+			res.Hostname = target
+			res.Port = port
+
+			// address is usually included as an additional record
+			res.Addr = addrFromRecords(records, target)
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return Resolved{}, err
+	}
+
+	if res.Hostname == "" {
+		return Resolved{}, fmt.Errorf("%w (%s)", ErrNotFound, name)
+	}
+
+	// query the target if its address wasn't included
+	if res.Addr == "" {
+		res.Addr, err = resolveHost(ctx, res.Hostname)
+		if err != nil {
+			return Resolved{}, err
+		}
+	}
+
+	return res, nil
+}
+
+// mdnsContext returns ctx limited to queryTimeout or half of ctx's remaining
+// time, whichever is shorter, so a fallback still has time once the mDNS
+// query is over
+func mdnsContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := queryTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline)/2)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Resolve resolves a .local hostname or Bonjour service instance name to the
+// device's current address. If mDNS isn't available or doesn't answer, a
+// hostname is resolved using the system resolver (which may itself support
+// mDNS) before giving up. The mDNS query waits at most 3 seconds or half of
+// ctx's remaining time, leaving the rest for the system resolver.
+func Resolve(ctx context.Context, name string) (Resolved, error) {
+	if !IsMDNSName(name) {
+		return Resolved{}, fmt.Errorf("mdns: %s is not a .local name", name)
+	}
+
+	if isServiceName(name) {
+		return resolveService(ctx, name)
+	}
+
+	mdnsCtx, cancel := mdnsContext(ctx)
+	addr, err := resolveHost(mdnsCtx, name)
+	cancel()
+	if err != nil {
+		// fall back to the system resolver
+		addrs, sysErr := net.DefaultResolver.LookupHost(ctx, name)
+		if sysErr != nil || len(addrs) == 0 {
+			return Resolved{}, err
+		}
+		addr = addrs[0]
+	}
+
+	return Resolved{
+		Hostname: strings.TrimSuffix(name, "."),
+		Addr:     addr,
+	}, nil
+}
+
+// PrinterConfig resolves cfg.Hostname if it is a .local hostname or Bonjour
+// service instance name. It returns the config (with Hostname set to the
+// device's .local hostname) and the option to pass to printer.NewPrinter so
+// it connects to the resolved address. Other hostnames are returned unchanged
+// with no options.
+func PrinterConfig(ctx context.Context, cfg printer.Config) (printer.Config, []printer.Option, error) {
+	if !IsMDNSName(cfg.Hostname) {
+		return cfg, nil, nil
+	}
+
+	res, err := Resolve(ctx, cfg.Hostname)
+	if err != nil {
+		return printer.Config{}, nil, err
+	}
+
+	cfg.Hostname = strings.TrimSuffix(res.Hostname, ".")
+
+	return cfg, []printer.Option{printer.WithDialAddress(res.Addr)}, nil
+}
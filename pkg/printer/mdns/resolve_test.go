@@ -0,0 +1,41 @@
+package mdns
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMDNSContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{"no deadline", 0, queryTimeout},
+		{"long deadline", time.Minute, queryTimeout},
+		{"short deadline", 2 * time.Second, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			mdnsCtx, cancel := mdnsContext(ctx)
+			defer cancel()
+
+			deadline, ok := mdnsCtx.Deadline()
+			if !ok {
+				t.Fatal("mdnsContext() has no deadline")
+			}
+			if got := time.Until(deadline); got > tt.want || got < tt.want-100*time.Millisecond {
+				t.Errorf("mdnsContext() deadline in %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package printer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockImportPage is a p12 import page, as the MFC-L2710DW renders it
+const mockImportPage = `<html><head><title>Import Certificate and Private Key</title></head><body>
+<form method="post" action="import.html" enctype="multipart/form-data">
+<input type="hidden" id="pageid" name="pageid" value="390"/>
+<input type="hidden" id="CSRFToken" name="CSRFToken" value="dG9rZW4="/>
+<input type="hidden" name="hidden_certificate_process_control" value="1"/>
+<input type="hidden" name="B8ea" value=""/>
+<label for="B820">File</label><input type="file" id="B820" name="B820"/>
+<label for="B821">Enter Password</label><input type="password" id="B821" name="B821"/>
+<input type="hidden" name="hidden_cert_import_password" value=""/>
+<input type="hidden" name="B8f8" value=""/>
+</form></body></html>`
+
+// mockPrinter is a test server that mimics the pages of the web UI that
+// UploadNewCert uses
+type mockPrinter struct {
+	*httptest.Server
+
+	// basePath is the path prefix the web UI is served under (if any)
+	basePath string
+
+	// onImport (if set) handles import POSTs instead of installing the cert.
+	// install installs it (as the printer would) and returns its ID.
+	onImport func(w http.ResponseWriter, install func() string)
+
+	mu          sync.Mutex
+	certIDs     []string
+	nextID      int
+	importPosts int
+	// requests are the requests received, as `METHOD host path?query`
+	requests []string
+	// importFields are the names of the last import form's fields, in order
+	importFields []string
+}
+
+// newMockPrinter starts a mockPrinter with the specified certs installed
+func newMockPrinter(t *testing.T, certIDs ...string) *mockPrinter {
+	t.Helper()
+
+	m := &mockPrinter{
+		certIDs: certIDs,
+		nextID:  100,
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+// newMockPrinterTLS starts a mockPrinter that is served over https
+func newMockPrinterTLS(t *testing.T, certIDs ...string) *mockPrinter {
+	t.Helper()
+
+	m := &mockPrinter{
+		certIDs: certIDs,
+		nextID:  100,
+	}
+	m.Server = httptest.NewTLSServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+// dialClient returns a client that connects to m whatever the url's host,
+// and a func that returns the addresses it was asked to dial (so a printer
+// can be given any base url)
+func (m *mockPrinter) dialClient() (*http.Client, func() []string) {
+	var mu sync.Mutex
+	addrs := []string{}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			addrs = append(addrs, addr)
+			mu.Unlock()
+
+			return (&net.Dialer{}).DialContext(ctx, network, m.Listener.Addr().String())
+		},
+		// the test server's cert isn't valid for the url's host
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	return &http.Client{Transport: transport}, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string{}, addrs...)
+	}
+}
+
+// install adds a cert and returns its ID
+func (m *mockPrinter) install() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := strconv.Itoa(m.nextID)
+	m.nextID++
+	m.certIDs = append(m.certIDs, id)
+
+	return id
+}
+
+// requestLog returns the requests received so far
+func (m *mockPrinter) requestLog() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string{}, m.requests...)
+}
+
+func (m *mockPrinter) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.requests = append(m.requests, fmt.Sprintf("%s %s %s", r.Method, r.Host, r.URL.RequestURI()))
+	m.mu.Unlock()
+
+	path, ok := strings.CutPrefix(r.URL.Path, m.basePath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case path == urlCertList:
+		m.mu.Lock()
+		ids := append([]string{}, m.certIDs...)
+		m.mu.Unlock()
+
+		var b strings.Builder
+		b.WriteString(`<html><body><table><tr><th>Certificate Name</th><th>Issuer</th></tr>`)
+		for _, id := range ids {
+			fmt.Fprintf(&b, `<tr><td>cert-%s</td><td>ca</td><td><a href="view.html?idx=%s">View</a></td></tr>`, id, id)
+		}
+		b.WriteString(`</table></body></html>`)
+		_, _ = w.Write([]byte(b.String()))
+
+	case path == urlCertImport && r.Method == http.MethodGet:
+		_, _ = w.Write([]byte(mockImportPage))
+
+	case path == urlCertImport && r.Method == http.MethodPost:
+		m.recordImportForm(r)
+
+		if m.onImport != nil {
+			m.onImport(w, m.install)
+			return
+		}
+		m.install()
+		_, _ = w.Write([]byte(`<html><body><p>Importing. Please wait.</p></body></html>`))
+
+	case path == urlCertView:
+		fmt.Fprintf(w, `<html><body><dl><dt>Issuer</dt><dd>ca</dd></dl></body></html>`)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// recordImportForm records the field names of an import POST, in order
+func (m *mockPrinter) recordImportForm(r *http.Request) {
+	fields := []string{}
+
+	reader, err := r.MultipartReader()
+	if err == nil {
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			fields = append(fields, part.FormName())
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.importPosts++
+	m.importFields = fields
+}
+
+// testKeyCert returns the pem of key and of a self-signed cert for it
+func testKeyCert(t *testing.T, key crypto.Signer, cn string) (keyPem, certPem []byte) {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create cert: %v", err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// testECKeyCert returns testKeyCert for a new P-256 key
+func testECKeyCert(t *testing.T, cn string) (keyPem, certPem []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return testKeyCert(t, key, cn)
+}
+
+// multipartFieldNames returns the names of a multipart body's fields, in
+// order
+func multipartFieldNames(t *testing.T, body []byte, boundary string) []string {
+	t.Helper()
+
+	names := []string{}
+	reader := multipart.NewReader(strings.NewReader(string(body)), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		names = append(names, part.FormName())
+	}
+
+	return names
+}
@@ -0,0 +1,133 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const urlDeviceInfo = "/general/information.html"
+
+// modelPages are the pages DetectModel reads, in order: the device info page
+// (model and firmware), then the status page, whose title names the model on
+// models without a device info page
+var modelPages = []string{urlDeviceInfo, urlLogin}
+
+var (
+	errModelNotFound = errors.New("printer: failed to find model name on device info page")
+
+	// e.g. `Model Name` or `Model`
+	regexLabelModel = regexp.MustCompile(`(?i)^\s*model(?:\s+name)?\s*:?\s*$`)
+	// e.g. `Main Firmware Version` or `Firmware Version`
+	regexLabelFirmware = regexp.MustCompile(`(?i)firmware(?:\s+version)?`)
+	// e.g. `<th>Model Name</th><td>MFC-L2710DW</td>`
+	regexTableHeaderCell = regexp.MustCompile(`(?is)<t[hd][^>]*>(.*?)</t[hd]>`)
+	// e.g. `Brother MFC-L2710DW series` (a page title)
+	regexTitleModel = regexp.MustCompile(`(?i)\bBrother\s+([A-Z]{2,4}-[A-Z0-9]+)`)
+)
+
+// ModelInfo identifies the printer's model and firmware
+type ModelInfo struct {
+	Model    string
+	Firmware string
+}
+
+// parseModelInfo returns the model and firmware shown on the device info page
+func parseModelInfo(bodyBytes []byte) (ModelInfo, error) {
+	info := ModelInfo{}
+
+	// label and value pairs are either a definition list or table rows
+	pairs := [][2]string{}
+	for _, detail := range regexCertViewDetail.FindAllSubmatch(bodyBytes, -1) {
+		pairs = append(pairs, [2]string{htmlToText(detail[1]), htmlToText(detail[2])})
+	}
+	for _, row := range regexTableRow.FindAllSubmatch(bodyBytes, -1) {
+		cells := regexTableHeaderCell.FindAllSubmatch(row[1], -1)
+		if len(cells) < 2 {
+			continue
+		}
+		pairs = append(pairs, [2]string{htmlToText(cells[0][1]), htmlToText(cells[1][1])})
+	}
+
+	for _, pair := range pairs {
+		label, value := pair[0], strings.TrimSpace(pair[1])
+		if value == "" {
+			continue
+		}
+
+		switch {
+		case info.Model == "" && regexLabelModel.MatchString(label):
+			info.Model = value
+		case info.Firmware == "" && regexLabelFirmware.MatchString(label):
+			info.Firmware = value
+		}
+	}
+
+	// else the page title (no firmware)
+	if info.Model == "" {
+		caps := regexTitle.FindSubmatch(bodyBytes)
+		if len(caps) == 2 {
+			if titleCaps := regexTitleModel.FindStringSubmatch(htmlToText(caps[1])); len(titleCaps) == 2 {
+				info.Model = titleCaps[1]
+			}
+		}
+	}
+
+	if info.Model == "" {
+		return ModelInfo{}, errModelNotFound
+	}
+
+	return info, nil
+}
+
+// DetectModel reads the printer's device info page and returns its model and
+// firmware version. If the model has no device info page, the model is taken
+// from the status page's title instead (without the firmware). The result is
+// cached, so the pages are only read once (a failure is cached too, unless it
+// may be temporary, e.g. a network error). If neither page names the model,
+// ErrUnsupported is returned.
+func (p *printer) DetectModel() (ModelInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.detectModel(ctx)
+}
+
+// detectModel performs DetectModel using ctx
+func (p *printer) detectModel(ctx context.Context) (ModelInfo, error) {
+	p.modelMu.Lock()
+	defer p.modelMu.Unlock()
+
+	if p.modelInfo != nil {
+		return *p.modelInfo, nil
+	}
+	if p.modelErr != nil {
+		return ModelInfo{}, p.modelErr
+	}
+
+	for _, path := range modelPages {
+		bodyBytes, err := p.getOptionalPage(ctx, path)
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		} else if err != nil {
+			return ModelInfo{}, err
+		}
+
+		info, err := parseModelInfo(bodyBytes)
+		if err != nil {
+			p.logger.DebugContext(ctx, "printer: model not found on page", "path", path, "error", err)
+			continue
+		}
+
+		p.modelInfo = &info
+		return info, nil
+	}
+
+	p.modelErr = fmt.Errorf("%w (%s)", ErrUnsupported, errModelNotFound)
+	return ModelInfo{}, p.modelErr
+}
@@ -0,0 +1,58 @@
+package printer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDetectModelCachesFailure(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for range 3 {
+		_, err = p.DetectModel()
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("DetectModel() error = %v, want %v", err, ErrUnsupported)
+		}
+	}
+	// device info and status pages, once
+	if got := requests.Load(); got != 2 {
+		t.Errorf("DetectModel() made %d requests, want 2", got)
+	}
+}
+
+func TestDetectModelFromStatusTitle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != urlLogin {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<html><head><title>Brother HL-L2350DW series</title></head><body></body></html>`))
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	info, err := p.DetectModel()
+	if err != nil {
+		t.Fatalf("DetectModel() error = %v", err)
+	}
+	if want := (ModelInfo{Model: "HL-L2350DW"}); info != want {
+		t.Errorf("DetectModel() = %+v, want %+v", info, want)
+	}
+}
@@ -0,0 +1,143 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrDeviceNotBackOnline is returned when the printer doesn't respond again
+// within the online timeout after restarting (e.g. to activate a cert). The
+// change may still have been applied.
+var ErrDeviceNotBackOnline = errors.New("printer: device not back online after restart")
+
+// defaults for WithOnlineWait
+const (
+	defaultOnlineInterval = 5 * time.Second
+	defaultOnlineTimeout  = 3 * time.Minute
+)
+
+// offlineInterval and offlineTimeout control polling of the printer while
+// waiting for it to go down (before waiting for it to come back). If it isn't
+// seen down within offlineTimeout, it is assumed to have restarted between
+// checks.
+const (
+	offlineInterval = 1 * time.Second
+	offlineTimeout  = 30 * time.Second
+)
+
+// WithOnlineWait sets how often the printer is checked, and for how long,
+// while waiting for it to come back online after a restart (see
+// WaitForOnline). The defaults are every 5 seconds for up to 3 minutes.
+func WithOnlineWait(interval, timeout time.Duration) Option {
+	return func(p *printer) {
+		p.onlineInterval = interval
+		p.onlineTimeout = timeout
+	}
+}
+
+// WithRebootTimeout sets how long to wait for the printer to come back online
+// after a restart (see WithOnlineWait), independently of the timeout of each
+// request (see WithRequestTimeout). The default is 3 minutes.
+func WithRebootTimeout(d time.Duration) Option {
+	return func(p *printer) {
+		p.onlineTimeout = d
+	}
+}
+
+// WaitForOnline waits for the printer to restart: it polls the printer's top
+// page until it stops responding (or for up to 30 seconds, in case the
+// restart was missed) and then until it responds again. Methods that restart
+// the printer (SetActiveCert, RebootPrinter, and SetHTTPSEnabled) return once
+// the change is confirmed, without waiting; RotateCert waits before verifying
+// the new cert. If the printer isn't online within the timeout set by
+// WithOnlineWait, an error wrapping ErrDeviceNotBackOnline is returned; if ctx
+// is done first, ctx's error is returned.
+func (p *printer) WaitForOnline(ctx context.Context) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+
+	return p.waitForOnline(ctx)
+}
+
+// waitForOnline performs WaitForOnline using ctx
+func (p *printer) waitForOnline(ctx context.Context) error {
+	// the printer isn't logged in to while it restarts
+	pollCtx, cancel := context.WithTimeout(context.WithValue(ctx, noReloginKey{}, true), p.onlineTimeout)
+	defer cancel()
+
+	// wait for the printer to go down, so it isn't seen online before then
+	downCtx, cancelDown := context.WithTimeout(pollCtx, offlineTimeout)
+	for p.checkOnline(downCtx) == nil {
+		err := sleepContext(downCtx, offlineInterval)
+		if err != nil {
+			break
+		}
+	}
+	cancelDown()
+
+	// track last result to explain a timeout
+	lastResult := "printer never responded"
+
+	for pollCtx.Err() == nil {
+		err := p.checkOnline(pollCtx)
+		if err == nil {
+			return nil
+		}
+		lastResult = err.Error()
+
+		// wait and try again
+		err = sleepContext(pollCtx, p.onlineInterval)
+		if err != nil {
+			break
+		}
+	}
+
+	// caller's ctx done, rather than the online timeout?
+	if ctx.Err() != nil {
+		return fmt.Errorf("printer: wait for online: %w", ctx.Err())
+	}
+
+	return fmt.Errorf("%w (waited %s) (%s)", ErrDeviceNotBackOnline, p.onlineTimeout, lastResult)
+}
+
+// checkOnline fetches the printer's top page and returns nil if it responds
+// with 200 OK or a redirect (e.g. from http to https, which the client doesn't
+// follow)
+func (p *printer) checkOnline(ctx context.Context) error {
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return err
+	}
+	u.Path = p.urlPath(urlLogin)
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// read and discard entire body
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// OK or redirect status?
+	if resp.StatusCode != http.StatusOK && (resp.StatusCode < 300 || resp.StatusCode > 399) {
+		return newHTTPStatusError("get of top page", resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package printer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForOnlineWaitsForRestart(t *testing.T) {
+	// up (not yet restarted), then down, then redirecting to https
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusOK)
+		case 2, 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.Redirect(w, r, "https://printer.example.com/", http.StatusMovedPermanently)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(srv.URL, WithOnlineWait(10*time.Millisecond, 5*time.Second))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = p.WaitForOnline(context.Background())
+	if err != nil {
+		t.Fatalf("WaitForOnline() error = %v", err)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("WaitForOnline() made %d requests, want 4", got)
+	}
+}
@@ -0,0 +1,99 @@
+package printer
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultHTTPSPort is the port https is assumed to be on, unless the base url
+// is https with a port (or WithPort is used with https)
+const defaultHTTPSPort = "443"
+
+var errPortInvalid = errors.New("printer: port must be between 1 and 65535")
+
+// WithPort sets the port the printer's web UI is on, if it isn't the default
+// for the scheme (443 for https, 80 for http). A port in the hostname or
+// base url (e.g. `https://10.0.0.5:8443`) works the same way; this option
+// overrides it. TLS checks of the printer's served cert (e.g.
+// GetCurrentLeafCert and VerifyActiveCert) use the port if the web UI is
+// https, and 443 otherwise.
+func WithPort(port int) Option {
+	return func(p *printer) {
+		p.port = port
+	}
+}
+
+// withPort returns baseUrl with the port set by WithPort (if any)
+func (p *printer) withPort(baseUrl string) (string, error) {
+	if p.port == 0 {
+		return baseUrl, nil
+	}
+	if p.port < 0 || p.port > 65535 {
+		return "", errPortInvalid
+	}
+
+	u, err := url.ParseRequestURI(baseUrl)
+	if err != nil {
+		return "", err
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(p.port))
+
+	return u.String(), nil
+}
+
+// httpsAddr returns the printer's hostname and the host:port address of its
+// https server, derived from the base url
+func (p *printer) httpsAddr() (hostname string, addr string, err error) {
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return "", "", err
+	}
+
+	port := defaultHTTPSPort
+	if u.Scheme == "https" && u.Port() != "" {
+		port = u.Port()
+	}
+
+	// tls server name can't include an IPv6 zone (e.g. `fe80::1%eth0`)
+	hostname, _, _ = strings.Cut(u.Hostname(), "%")
+
+	return hostname, net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// urlHost returns the configured hostname (which may include a port) in the
+// form used in a url. IPv6 literals are bracketed and their zone (if any) is
+// escaped, e.g. `fe80::1%eth0` becomes `[fe80::1%25eth0]`.
+func urlHost(hostname string) string {
+	// bare IPv6 literal (can't include a port)
+	if !strings.HasPrefix(hostname, "[") && strings.Count(hostname, ":") > 1 {
+		hostname = "[" + hostname + "]"
+	}
+
+	// escape zone, unless it already is
+	before, zone, found := strings.Cut(hostname, "%")
+	if found && !isEscapedZone(zone) {
+		hostname = before + "%25" + zone
+	}
+
+	return hostname
+}
+
+// isEscapedZone returns true if zone (the part of a url host after `%`) is
+// already escaped, i.e. the `%` is a valid `%25` escape of a non-empty zone
+// and any other escapes in the zone are valid too. An unescaped zone (e.g.
+// `eth0`) isn't a valid escape after the `%`.
+func isEscapedZone(zone string) bool {
+	// drop the closing bracket and port (if any)
+	zone, _, _ = strings.Cut(zone, "]")
+
+	escaped, found := strings.CutPrefix(zone, "25")
+	if !found || escaped == "" {
+		return false
+	}
+
+	_, err := url.PathUnescape(escaped)
+	return err == nil
+}
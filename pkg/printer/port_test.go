@@ -0,0 +1,183 @@
+package printer
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUploadNewCertPortURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseUrl  string
+		opts     []Option
+		wantHost string
+		wantDial string
+	}{
+		{
+			name:     "port in base url",
+			baseUrl:  "https://10.0.0.5:8443",
+			wantHost: "10.0.0.5:8443",
+			wantDial: "10.0.0.5:8443",
+		},
+		{
+			name:     "WithPort",
+			baseUrl:  "https://10.0.0.5",
+			opts:     []Option{WithPort(8443)},
+			wantHost: "10.0.0.5:8443",
+			wantDial: "10.0.0.5:8443",
+		},
+		{
+			name:     "WithPort overrides base url",
+			baseUrl:  "https://10.0.0.5:9443",
+			opts:     []Option{WithPort(8443)},
+			wantHost: "10.0.0.5:8443",
+			wantDial: "10.0.0.5:8443",
+		},
+		{
+			name:     "default port",
+			baseUrl:  "https://10.0.0.5",
+			wantHost: "10.0.0.5",
+			wantDial: "10.0.0.5:443",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockPrinterTLS(t, "1")
+			client, dialed := m.dialClient()
+
+			p, err := New(tt.baseUrl, append([]Option{WithHTTPClient(client), WithPollInterval(10 * time.Millisecond)}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			keyPem, certPem := testECKeyCert(t, "printer.example.com")
+			_, err = p.UploadNewCert(keyPem, certPem)
+			if err != nil {
+				t.Fatalf("UploadNewCert() error = %v", err)
+			}
+
+			want := "POST " + tt.wantHost + " " + urlCertImport
+			if !slices.Contains(m.requestLog(), want) {
+				t.Errorf("no request %q in %q", want, m.requestLog())
+			}
+			for _, addr := range dialed() {
+				if addr != tt.wantDial {
+					t.Errorf("dialed %q, want %q", addr, tt.wantDial)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPSAddr(t *testing.T) {
+	tests := []struct {
+		baseUrl      string
+		opts         []Option
+		wantHostname string
+		wantAddr     string
+	}{
+		{"https://10.0.0.5:8443", nil, "10.0.0.5", "10.0.0.5:8443"},
+		{"https://10.0.0.5", []Option{WithPort(8443)}, "10.0.0.5", "10.0.0.5:8443"},
+		{"https://10.0.0.5", nil, "10.0.0.5", "10.0.0.5:443"},
+		// the http port isn't the https port
+		{"http://10.0.0.5:8080", nil, "10.0.0.5", "10.0.0.5:443"},
+		{"https://[fe80::1%25eth0]:8443", nil, "fe80::1", "[fe80::1%eth0]:8443"},
+	}
+
+	for _, tt := range tests {
+		p, err := New(tt.baseUrl, tt.opts...)
+		if err != nil {
+			t.Fatalf("New(%q) error = %v", tt.baseUrl, err)
+		}
+
+		hostname, addr, err := p.httpsAddr()
+		if err != nil {
+			t.Fatalf("httpsAddr() error = %v", err)
+		}
+		if hostname != tt.wantHostname || addr != tt.wantAddr {
+			t.Errorf("New(%q).httpsAddr() = %q, %q, want %q, %q", tt.baseUrl, hostname, addr, tt.wantHostname, tt.wantAddr)
+		}
+	}
+}
+
+func TestWithPortInvalid(t *testing.T) {
+	for _, port := range []int{-1, 65536} {
+		_, err := New("https://10.0.0.5", WithPort(port))
+		if err != errPortInvalid {
+			t.Errorf("New() with port %d error = %v, want %v", port, err, errPortInvalid)
+		}
+	}
+}
+
+func TestURLHost(t *testing.T) {
+	tests := []struct {
+		hostname string
+		want     string
+	}{
+		{"10.0.0.5", "10.0.0.5"},
+		{"10.0.0.5:8443", "10.0.0.5:8443"},
+		{"printer.example.com", "printer.example.com"},
+		{"fe80::1", "[fe80::1]"},
+		{"[fe80::1]:8443", "[fe80::1]:8443"},
+		{"fe80::1%eth0", "[fe80::1%25eth0]"},
+		{"[fe80::1%eth0]:8443", "[fe80::1%25eth0]:8443"},
+		// already escaped
+		{"[fe80::1%25eth0]", "[fe80::1%25eth0]"},
+		{"[fe80::1%25eth0]:8443", "[fe80::1%25eth0]:8443"},
+		// zones that start with 25 but aren't escaped
+		{"fe80::1%25", "[fe80::1%2525]"},
+		{"fe80::1%25%", "[fe80::1%2525%]"},
+	}
+
+	for _, tt := range tests {
+		if got := urlHost(tt.hostname); got != tt.want {
+			t.Errorf("urlHost(%q) = %q, want %q", tt.hostname, got, tt.want)
+		}
+	}
+}
+
+func TestUploadNewCertIPv6URL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseUrl  string
+		wantDial string
+	}{
+		{"zone", "https://[fe80::1%25eth0]", "[fe80::1%eth0]:443"},
+		{"zone and port", "https://[fe80::1%25eth0]:8443", "[fe80::1%eth0]:8443"},
+		{"hostname with zone", "https://" + urlHost("fe80::1%eth0"), "[fe80::1%eth0]:443"},
+		{"no zone", "https://[2001:db8::5]", "[2001:db8::5]:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockPrinterTLS(t, "1")
+			client, dialed := m.dialClient()
+
+			p, err := New(tt.baseUrl, WithHTTPClient(client), WithPollInterval(10*time.Millisecond))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			keyPem, certPem := testECKeyCert(t, "printer.example.com")
+			_, err = p.UploadNewCert(keyPem, certPem)
+			if err != nil {
+				t.Fatalf("UploadNewCert() error = %v", err)
+			}
+
+			if m.importPosts != 1 {
+				t.Errorf("import POSTed %d times, want 1", m.importPosts)
+			}
+			addrs := dialed()
+			if len(addrs) == 0 {
+				t.Fatal("nothing dialed")
+			}
+			for _, addr := range addrs {
+				if addr != tt.wantDial {
+					t.Errorf("dialed %q, want %q", addr, tt.wantDial)
+				}
+			}
+		})
+	}
+}
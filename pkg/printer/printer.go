@@ -1,71 +1,511 @@
 package printer
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// printer is a struct to interact with a remote Brother printer
+var errInsecureTLSWithClient = errors.New("printer: WithInsecureTLS can't be combined with WithHTTPClient (set InsecureSkipVerify in the client's transport instead)")
+
+// printer is a struct to interact with a remote Brother printer. It is safe
+// for concurrent use: operations that change the printer (e.g. upload,
+// delete, setting the active cert, and reboot) are serialized, since
+// interleaved form submissions invalidate each other's CSRFTokens, and
+// read-only operations wait for any change in progress.
 type printer struct {
+	// mu serializes operations (read-only ones share it)
+	mu sync.RWMutex
+
+	// loginMu serializes logins, since read-only operations run concurrently
+	// and may each find the session ended. logins counts the completed ones.
+	loginMu sync.Mutex
+	logins  atomic.Uint64
+
 	httpClient *http.Client
+	transport  *printerTransport
 	baseUrl    string
+	password   string
+
+	// operationBudget is the max time allowed for one high-level operation
+	// (0 == no limit)
+	operationBudget time.Duration
+
+	// httpsUpgrade switches to https if the printer requires it
+	httpsUpgrade bool
+
+	// pollInterval and pollTimeout control polling of the cert list for the
+	// new cert after an upload, and to verify a delete
+	pollInterval time.Duration
+	pollTimeout  time.Duration
+
+	// callerClient and httpTimeout are the client and timeout set by
+	// options (if any)
+	callerClient *http.Client
+	httpTimeout  time.Duration
+
+	// stagedHttpSettings is the cert change waiting for RebootPrinter (if any)
+	stagedHttpSettings *stagedHttpSettings
+
+	// maxValidity is the longest cert validity period the printer accepts
+	// (0 == no limit)
+	maxValidity time.Duration
+
+	// logger receives debug logs of operations
+	logger *slog.Logger
+
+	// port is the web UI port set by WithPort (if any)
+	port int
+
+	// dryRun skips submitting forms that change the printer
+	dryRun bool
+
+	// modelInfo is the cached result of DetectModel (nil until detected), and
+	// modelErr the cached error if the model can't be detected
+	modelMu   sync.Mutex
+	modelInfo *ModelInfo
+	modelErr  error
+
+	// onlineInterval and onlineTimeout control polling of the printer while
+	// it restarts
+	onlineInterval time.Duration
+	onlineTimeout  time.Duration
+
+	// progressFunc is called as long operations progress (if set)
+	progressFunc ProgressFunc
+
+	// basePath is the path prefix of the web UI (e.g. behind a reverse
+	// proxy), or "" if it is at the root
+	basePath string
+
+	// insecureTLS skips verification of the printer's tls cert
+	insecureTLS bool
+
+	// uploadRetries is how many times an upload whose POST failed (without
+	// installing the cert) is started over
+	uploadRetries int
+}
+
+// Option modifies the printer when passed to NewPrinter
+type Option func(*printer)
+
+// WithOperationBudget sets an overall deadline for each high-level operation
+// (e.g. UploadNewCert). The budget covers all of the operation's HTTP round
+// trips, any wait for the printer to process changes, and verification.
+func WithOperationBudget(d time.Duration) Option {
+	return func(p *printer) {
+		p.operationBudget = d
+	}
+}
+
+// WithHTTPClient makes the printer use a copy of the specified client. The
+// client's Transport (if any) is used for requests, but redirects are never
+// followed and a cookie jar is added if the client has none. WithDialAddress
+// doesn't apply to a custom Transport.
+//
+// Every request the printer makes (other than tls handshakes that inspect the
+// printer's served cert) goes through this client, so it can also point the
+// package at a test server, e.g. with New(srv.URL, WithHTTPClient(srv.Client())).
+//
+// This is the way to customize tls (e.g. a Transport whose TLSClientConfig
+// has a RootCAs pool containing the printer's cert, or InsecureSkipVerify) or
+// to disable http/2 (a Transport with an empty, non-nil TLSNextProto). See
+// also WithInsecureTLS, which can't be combined with this.
+func WithHTTPClient(c *http.Client) Option {
+	return func(p *printer) {
+		p.callerClient = c
+	}
+}
+
+// WithInsecureTLS makes the printer skip verification of the printer's tls
+// cert, which is needed for a new printer that still has its default
+// self-signed cert. It makes its own client, so it can't be combined with
+// WithHTTPClient (NewPrinter returns an error); set InsecureSkipVerify in
+// that client's Transport instead.
+func WithInsecureTLS() Option {
+	return func(p *printer) {
+		p.insecureTLS = true
+	}
+}
+
+// WithTimeout sets the timeout of each http request. The default is 30
+// seconds (or the timeout of the client from WithHTTPClient). It is ignored
+// if WithRequestTimeout is used.
+func WithTimeout(d time.Duration) Option {
+	return func(p *printer) {
+		p.httpTimeout = d
+	}
+}
+
+// WithPollInterval sets how often the printer's cert list is checked for the
+// new cert after an upload (or for the deleted cert to be gone after a
+// delete). The default is every 500ms.
+func WithPollInterval(d time.Duration) Option {
+	return func(p *printer) {
+		p.pollInterval = d
+	}
+}
+
+// WithPollTimeout sets how long to wait for the new cert to appear in the
+// printer's cert list after an upload (or for the deleted cert to be gone
+// after a delete). The default is 30 seconds; slow models may need longer.
+func WithPollTimeout(d time.Duration) Option {
+	return func(p *printer) {
+		p.pollTimeout = d
+	}
+}
+
+// WithCorrelationID tags the printer's operations with the specified ID so
+// they can be correlated with the caller's logs. The ID is sent with every
+// request in the X-Correlation-ID header, and included in the debug logs (see
+// WithLogger) as correlation_id.
+func WithCorrelationID(id string) Option {
+	return func(p *printer) {
+		p.transport.correlationID = id
+	}
+}
+
+// WithDialAddress makes the printer connect to the specified address (e.g. an
+// IP resolved via mDNS) instead of resolving the configured Hostname. The
+// Hostname is still used in requests and for tls.
+func WithDialAddress(host string) Option {
+	return func(p *printer) {
+		p.transport.dialHost = host
+	}
+}
+
+// CorrelationID returns the ID set by WithCorrelationID (if any)
+func (p *printer) CorrelationID() string {
+	return p.transport.correlationID
+}
+
+// operationContext returns a context for a single high-level operation which
+// is bounded by the printer's operation budget (if one is set)
+func (p *printer) operationContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if p.operationBudget > 0 {
+		return context.WithTimeout(parent, p.operationBudget)
+	}
+
+	return context.WithCancel(parent)
+}
+
+// sleepContext pauses for the specified duration, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // PrinterConfig contains the information necessary to create a printer
 // type which interfaces with a remote Brother printer
 type Config struct {
+	// Hostname may include a port (e.g. `10.0.0.5:8443`) if the web UI isn't
+	// on the default port (see also WithPort). IPv6 literals may be bare
+	// (e.g. `fe80::1%eth0`) or bracketed (`[fe80::1%eth0]:8443`).
 	Hostname  string
 	Password  string
 	UserAgent string
 	UseHttp   bool
 }
 
-// custom transport to add User-Agent
+// custom transport to add User-Agent and observe the printer's tls cert
 type printerTransport struct {
-	userAgent string
+	base          http.RoundTripper
+	userAgent     string
+	correlationID string
+
+	// dialHost is the address to connect to instead of the url's host
+	// (if set)
+	dialHost string
+
+	// requestEditors are called on each request before it is sent
+	requestEditors []RequestEditor
+
+	// requestTimeout bounds each request (0 == only the client's Timeout)
+	requestTimeout time.Duration
+
+	// relogin logs in again when a response shows the session isn't logged
+	// in (unless there was a login since logins returned the count), and jar
+	// is the client's cookie jar
+	relogin func(ctx context.Context, logins uint64) error
+	logins  func() uint64
+	jar     http.CookieJar
+
+	// getRetries and postRetries are how many times failed requests are
+	// retried, by method
+	getRetries  int
+	postRetries int
+	retryPolicy *RetryPolicy
+
+	// lastServerCert is the leaf cert the printer presented on the most
+	// recent tls connection
+	lastServerCertMu sync.Mutex
+	lastServerCert   *x509.Certificate
+
+	// sessionTimeout is the printer's session timeout (0 == unknown or not
+	// supported), sessionTimeoutFetched is true once it has been read or set,
+	// and lastRequest is when the most recent request was made
+	sessionMu             sync.Mutex
+	sessionTimeout        time.Duration
+	sessionTimeoutFetched bool
+	lastRequest           time.Time
+
+	// logger receives debug logs of requests
+	logger *slog.Logger
 }
 
 func (trans *printerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// always set user-agent
-	req.Header.Set("User-Agent", trans.userAgent)
+	// always set user-agent (if there is one)
+	if trans.userAgent != "" {
+		req.Header.Set("User-Agent", trans.userAgent)
+	}
 
-	return http.DefaultTransport.RoundTrip(req)
+	// tag request for tracing (e.g. in proxy logs)
+	if trans.correlationID != "" {
+		req.Header.Set("X-Correlation-ID", trans.correlationID)
+	}
+
+	// caller's edits
+	err := trans.editRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// track session activity
+	trans.sessionMu.Lock()
+	trans.lastRequest = time.Now()
+	trans.sessionMu.Unlock()
+
+	start := time.Now()
+	resp, err := trans.roundTripWithRelogin(req)
+	trans.logRoundTrip(req, resp, err, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// record the cert the printer presented
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		trans.setLastServerCert(resp.TLS.PeerCertificates[0])
+	}
+
+	return resp, nil
 }
 
-// NewPrinter creates a new printer from a PrinterConfig
-func NewPrinter(cfg Config) (*printer, error) {
-	baseUrl := "https://" + cfg.Hostname
-	// http instead?
-	if cfg.UseHttp {
-		baseUrl = "http://" + cfg.Hostname
+// dialAddr returns the address to connect to for the specified host:port
+// address
+func (trans *printerTransport) dialAddr(addr string) string {
+	if trans.dialHost == "" {
+		return addr
+	}
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
 	}
 
+	return net.JoinHostPort(trans.dialHost, port)
+}
+
+// setLastServerCert records cert as the last cert presented by the printer
+func (trans *printerTransport) setLastServerCert(cert *x509.Certificate) {
+	trans.lastServerCertMu.Lock()
+	defer trans.lastServerCertMu.Unlock()
+
+	trans.lastServerCert = cert
+}
+
+// LastServerCert returns the leaf certificate the printer presented on the
+// most recent tls connection made by any operation, or nil if no tls
+// connection has been made (e.g. when using http)
+func (p *printer) LastServerCert() *x509.Certificate {
+	p.transport.lastServerCertMu.Lock()
+	defer p.transport.lastServerCertMu.Unlock()
+
+	return p.transport.lastServerCert
+}
+
+// New creates a new printer for the printer at baseUrl (e.g.
+// https://printer.example.com) using any options. Unlike NewPrinter, it
+// doesn't log in.
+func New(baseUrl string, opts ...Option) (*printer, error) {
+	// validate base url now, rather than failing on every request
+	u, err := url.ParseRequestURI(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("printer: invalid base url (%w)", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("printer: invalid base url '%s' (must be http or https with a host)", baseUrl)
+	}
+
+	return newPrinter(strings.TrimSuffix(baseUrl, "/"), "", opts...)
+}
+
+// newPrinter creates a new printer using any options
+func newPrinter(baseUrl string, userAgent string, opts ...Option) (*printer, error) {
 	// make cookie jar
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, err
 	}
 
+	// default user-agent (instead of Go's)
+	if userAgent == "" {
+		userAgent = defaultUserAgent()
+	}
+
+	transport := &printerTransport{
+		base:      http.DefaultTransport,
+		userAgent: userAgent,
+	}
+
 	p := &printer{
-		httpClient: &http.Client{
-			// disable redirect (POSTs return 301 and if client follows it loses the post response)
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
-			Jar: jar,
-
-			// set client timeout
-			Timeout: 30 * time.Second,
-			Transport: &printerTransport{
-				userAgent: cfg.UserAgent,
-			},
-		},
-		baseUrl: baseUrl,
+		transport: transport,
+		baseUrl:   baseUrl,
+
+		pollInterval: 500 * time.Millisecond,
+		pollTimeout:  30 * time.Second,
+
+		onlineInterval: defaultOnlineInterval,
+		onlineTimeout:  defaultOnlineTimeout,
+
+		logger: slog.New(slog.DiscardHandler),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(p)
+	}
+	transport.logger = p.logger
+
+	p.baseUrl, err = p.withPort(p.baseUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	// base path from the option, else from the base url
+	if p.basePath == "" {
+		u, err := url.ParseRequestURI(p.baseUrl)
+		if err != nil {
+			return nil, err
+		}
+		p.basePath = u.Path
+	}
+	p.basePath = normalizeBasePath(p.basePath)
+
+	// can't both use the caller's client and make one
+	if p.insecureTLS && p.callerClient != nil {
+		return nil, errInsecureTLSWithClient
+	}
+
+	// caller's client (copied so it isn't modified) or a new one
+	client := &http.Client{
+		// set client timeout
+		Timeout: 30 * time.Second,
+	}
+	if p.callerClient != nil {
+		callerClient := *p.callerClient
+		client = &callerClient
+
+		if client.Transport != nil {
+			transport.base = client.Transport
+		}
+	}
+	if p.httpTimeout > 0 {
+		client.Timeout = p.httpTimeout
+	}
+	if transport.requestTimeout > 0 {
+		// each request is bounded by its context instead
+		client.Timeout = 0
+	}
+	if client.Jar == nil {
+		client.Jar = jar
+	}
+
+	// disable redirect (POSTs return 301 and if client follows it loses the post response)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	client.Transport = transport
+	p.httpClient = client
+
+	transport.jar = client.Jar
+	transport.relogin = p.relogin
+	transport.logins = p.logins.Load
+
+	// connect to a specific address and/or skip tls verification? (the
+	// caller's Transport, if any, is used as-is)
+	callerTransport := p.callerClient != nil && p.callerClient.Transport != nil
+	if (transport.dialHost != "" || p.insecureTLS) && !callerTransport {
+		base := http.DefaultTransport.(*http.Transport).Clone()
+
+		if transport.dialHost != "" {
+			dialer := &net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}
+
+			base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, transport.dialAddr(addr))
+			}
+		}
+
+		if p.insecureTLS {
+			base.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+
+		transport.base = base
+	}
+
+	return p, nil
+}
+
+// NewPrinter creates a new printer from a PrinterConfig and any options
+func NewPrinter(cfg Config, opts ...Option) (*printer, error) {
+	baseUrl := "https://" + urlHost(cfg.Hostname)
+	// http instead?
+	if cfg.UseHttp {
+		baseUrl = "http://" + urlHost(cfg.Hostname)
+	}
+
+	p, err := newPrinter(baseUrl, cfg.UserAgent, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Password != "" {
+		p.password = cfg.Password
 	}
 
 	// login & get cookie
-	err = p.login(cfg.Password)
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	err = p.login(ctx, p.password)
+	if errors.Is(err, ErrHTTPSRequired) && p.httpsUpgrade {
+		p.baseUrl, err = p.withPort("https://" + urlHost(cfg.Hostname))
+		if err != nil {
+			return nil, err
+		}
+		err = p.login(ctx, p.password)
+	}
 	if err != nil {
 		return nil, err
 	}
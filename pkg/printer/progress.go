@@ -0,0 +1,36 @@
+package printer
+
+// stages of long operations, as passed to a ProgressFunc
+const (
+	ProgressFetchingImportPage   = "fetching import page"
+	ProgressUploading            = "uploading"
+	ProgressFetchingDeletePage   = "fetching delete page"
+	ProgressDeleting             = "deleting"
+	ProgressFetchingHttpSettings = "fetching http settings"
+	ProgressSubmitting           = "submitting"
+	ProgressWaitingForDevice     = "waiting for device"
+	ProgressVerifying            = "verifying"
+	ProgressDone                 = "done"
+)
+
+// ProgressFunc is called as a long operation (e.g. UploadNewCert, DeleteCert,
+// or SetActiveCert) reaches each stage, with the approximate percent of the
+// operation that is complete. It is called synchronously, so it should return
+// quickly.
+type ProgressFunc func(stage string, pct int)
+
+// WithProgress sets a function which is called as long operations progress
+// (e.g. to render a progress bar)
+func WithProgress(f ProgressFunc) Option {
+	return func(p *printer) {
+		p.progressFunc = f
+	}
+}
+
+// progress reports the stage of the current operation (if a ProgressFunc is
+// set)
+func (p *printer) progress(stage string, pct int) {
+	if p.progressFunc != nil {
+		p.progressFunc(stage, pct)
+	}
+}
@@ -0,0 +1,33 @@
+package printer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RequestEditor edits a request before it is sent (e.g. to add a header). If
+// it returns an error, the request isn't sent and the error is returned.
+type RequestEditor func(req *http.Request) error
+
+// WithRequestEditor calls edit on every request to the printer (GETs and
+// POSTs of all operations, including logins) right before it is sent, after
+// the User-Agent and X-Correlation-ID headers are set. Retries of a request
+// are sent with the same edits. The option may be used more than once; the
+// editors are called in order.
+func WithRequestEditor(edit RequestEditor) Option {
+	return func(p *printer) {
+		p.transport.requestEditors = append(p.transport.requestEditors, edit)
+	}
+}
+
+// editRequest calls the request editors on req
+func (trans *printerTransport) editRequest(req *http.Request) error {
+	for _, edit := range trans.requestEditors {
+		err := edit(req)
+		if err != nil {
+			return fmt.Errorf("printer: request editor failed (%w)", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package printer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithRequestTimeout bounds each http request (each attempt, if requests
+// are retried) to d using its context, instead of the client's Timeout (which
+// is then not used, including the Timeout of the client from WithHTTPClient).
+// This is separate from how long the printer may take to restart (see
+// WithRebootTimeout), so requests can fail fast on a dead printer while
+// still allowing a long reboot window.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(p *printer) {
+		p.transport.requestTimeout = d
+	}
+}
+
+// cancelOnCloseBody is a response body that cancels its request's context
+// once it is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// baseRoundTrip performs the request using the base transport, bounded by
+// the request timeout (if one is set). The timeout covers reading the body.
+func (trans *printerTransport) baseRoundTrip(req *http.Request) (*http.Response, error) {
+	if trans.requestTimeout <= 0 {
+		return trans.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), trans.requestTimeout)
+	resp, err := trans.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
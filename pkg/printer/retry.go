@@ -0,0 +1,145 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// retryDelay is the delay before the first retry; each retry waits longer
+const retryDelay = 1 * time.Second
+
+// RetryPolicy controls retries of requests that fail with a network error or
+// a 5xx status
+type RetryPolicy struct {
+	// MaxAttempts is the most times a request is attempted (including the
+	// first attempt)
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry (default 1 second)
+	BaseDelay time.Duration
+	// Backoff multiplies the delay after each retry (default 2)
+	Backoff float64
+	// PostAttempts is the most times any POST is attempted (default 1, i.e.
+	// never retried). POSTs commit changes (e.g. an import or a delete) and
+	// retrying one may repeat it, so this should normally be left unset.
+	PostAttempts int
+}
+
+// WithRetries sets how many times a request that fails with a network error
+// or a 5xx status is retried.
+//
+// Deprecated: use WithRetry, which this is equivalent to with MaxAttempts of
+// getRetries+1 and PostAttempts of postRetries+1.
+func WithRetries(getRetries, postRetries int) Option {
+	return WithRetry(RetryPolicy{MaxAttempts: getRetries + 1, PostAttempts: postRetries + 1})
+}
+
+// WithRetry retries requests that fail with a network error or a 5xx status
+// (never a 4xx), waiting longer before each retry as set by policy. GETs
+// (which only load pages) are retried. POSTs that commit a change (e.g. a
+// delete or activating a cert) are not retried unless policy.PostAttempts is
+// set, since the change may have partially succeeded. The exception is the
+// POST of an upload: if the cert shows up in the printer's cert list within
+// the poll timeout (see WithPollTimeout), its ID is returned as if the POST
+// had succeeded; otherwise the upload starts over.
+func WithRetry(policy RetryPolicy) Option {
+	return func(p *printer) {
+		if policy.BaseDelay <= 0 {
+			policy.BaseDelay = retryDelay
+		}
+		if policy.Backoff < 1 {
+			policy.Backoff = 2
+		}
+
+		p.transport.getRetries = max(policy.MaxAttempts-1, 0)
+		p.transport.postRetries = max(policy.PostAttempts-1, 0)
+		p.transport.retryPolicy = &policy
+		p.uploadRetries = max(policy.MaxAttempts-1, 0)
+	}
+}
+
+// retryWait returns how long to wait before the specified retry (0 is the
+// first)
+func (trans *printerTransport) retryWait(attempt int) time.Duration {
+	if trans.retryPolicy == nil {
+		return retryDelay * time.Duration(attempt+1)
+	}
+
+	return time.Duration(float64(trans.retryPolicy.BaseDelay) * math.Pow(trans.retryPolicy.Backoff, float64(attempt)))
+}
+
+// isTransientError returns true if err is a network error or a 5xx status
+// (i.e. trying again may succeed)
+func isTransientError(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.StatusCode)
+	}
+
+	// errors of the http client (other than ones the printer itself handles)
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) &&
+		!errors.Is(err, ErrSessionExpired) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// retries returns the number of retries allowed for the request's method
+func (trans *printerTransport) retries(req *http.Request) int {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return trans.getRetries
+	default:
+		return trans.postRetries
+	}
+}
+
+// isRetryableStatus returns true for statuses that indicate the printer (or a
+// proxy) was temporarily unable to handle the request
+func isRetryableStatus(statusCode int) bool {
+	return statusCode >= 500 && statusCode <= 599
+}
+
+// roundTripWithRetries performs the request using the base transport
+// (bounded by the request timeout), retrying as allowed for its method
+func (trans *printerTransport) roundTripWithRetries(req *http.Request) (*http.Response, error) {
+	retries := trans.retries(req)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := trans.baseRoundTrip(req)
+
+		// done?
+		if attempt >= retries || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		// body must be sent again (if there is one)
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		// discard failed response
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		err = sleepContext(req.Context(), trans.retryWait(attempt))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// not all models have this page
+const urlSessionTimeout = "/admin/session.html"
+
+var (
+	errSessionTimeoutFieldNotFound = errors.New("printer: session timeout field not found on page")
+	errSessionTimeoutInvalid       = errors.New("printer: session timeout must be a positive whole number of minutes")
+)
+
+// e.g. `Session Timeout` or `Auto Logout Time`
+var regexLabelSessionTimeout = regexp.MustCompile(`(?i)time\s*-?\s*out|logout\s+time`)
+
+// sessionTimeoutField returns the name and current value (in minutes) of the
+// session timeout field (a text input or select) on the session settings page
+func sessionTimeoutField(bodyBytes []byte) (name string, minutes int, err error) {
+	labels := parseLabels(bodyBytes)
+	values := parseFormValues(bodyBytes)
+
+	// candidate fields are text inputs and selects, in page order
+	for _, f := range parseElements(bodyBytes, "input", "select") {
+		if f.tag == "input" {
+			switch strings.ToLower(f.attrs["type"]) {
+			case "", "text", "number":
+			default:
+				continue
+			}
+		}
+
+		name := f.attrs["name"]
+		if name == "" {
+			continue
+		}
+
+		label := precedingLabel(bodyBytes, f.start, f.attrs["id"], labels)
+		if !regexLabelSessionTimeout.MatchString(label) {
+			continue
+		}
+
+		minutes, err := strconv.Atoi(strings.TrimSpace(values.Get(name)))
+		if err != nil {
+			return "", 0, fmt.Errorf("printer: failed to parse session timeout value %q (%w)", values.Get(name), err)
+		}
+
+		return name, minutes, nil
+	}
+
+	return "", 0, errSessionTimeoutFieldNotFound
+}
+
+// GetSessionTimeout returns the web UI admin session (idle) timeout. If the
+// model doesn't have a session timeout setting, ErrUnsupported is returned.
+//
+// A short timeout can expire during long operations (e.g. waiting for the
+// printer to process a new cert). The printer logs in again before continuing
+// an operation that has been idle for longer than the timeout (which is
+// fetched the first time it is needed, if this function or SetSessionTimeout
+// hasn't been called yet).
+func (p *printer) GetSessionTimeout() (time.Duration, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+
+	return p.getSessionTimeout(ctx)
+}
+
+// getSessionTimeout performs GetSessionTimeout using ctx
+func (p *printer) getSessionTimeout(ctx context.Context) (time.Duration, error) {
+	// GET settings page
+	bodyBytes, err := p.getOptionalPage(ctx, urlSessionTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	_, minutes, err := sessionTimeoutField(bodyBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	timeout := time.Duration(minutes) * time.Minute
+	p.setSessionTimeout(timeout)
+
+	return timeout, nil
+}
+
+// SetSessionTimeout sets the web UI admin session (idle) timeout. The printer
+// only supports whole minutes. If the model doesn't have a session timeout
+// setting, ErrUnsupported is returned. See GetSessionTimeout regarding
+// operations that outlast the timeout.
+func (p *printer) SetSessionTimeout(timeout time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if timeout < time.Minute || timeout%time.Minute != 0 {
+		return errSessionTimeoutInvalid
+	}
+
+	ctx, cancel := p.operationContext(context.Background())
+	defer cancel()
+	ctx = p.dryRunContext(ctx, "set session timeout", "")
+
+	// GET settings page
+	bodyBytes, err := p.getOptionalPage(ctx, urlSessionTimeout)
+	if err != nil {
+		return err
+	}
+
+	fieldName, _, err := sessionTimeoutField(bodyBytes)
+	if err != nil {
+		return err
+	}
+
+	// form values are the page's current values with the timeout changed
+	data := parseFormValues(bodyBytes)
+	if !hasCSRFToken(data) {
+		csrfToken, err := parseBodyForCSRFToken(bodyBytes)
+		if err != nil {
+			return err
+		}
+		data.Set(csrfToken.name, csrfToken.value)
+	}
+	data.Set(fieldName, strconv.Itoa(int(timeout/time.Minute)))
+
+	// get url & set path
+	u, err := url.ParseRequestURI(p.baseUrl)
+	if err != nil {
+		return err
+	}
+	u.Path = p.urlPath(urlSessionTimeout)
+
+	// dry run?
+	if plan := dryRunPlan(ctx); plan != nil {
+		plan.planForm(u, data)
+		return plan
+	}
+
+	// make and do request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// read and discard entire body
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// OK status?
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError("post of session timeout", resp)
+	}
+
+	p.setSessionTimeout(timeout)
+
+	return nil
+}
+
+// setSessionTimeout records the printer's session timeout
+func (p *printer) setSessionTimeout(timeout time.Duration) {
+	p.transport.sessionMu.Lock()
+	defer p.transport.sessionMu.Unlock()
+
+	p.transport.sessionTimeout = timeout
+	p.transport.sessionTimeoutFetched = true
+}
+
+// refreshSession logs in again if no request has been made for longer than
+// the printer's session timeout (i.e. the session has likely expired). The
+// timeout is fetched on first use; if the model doesn't have the setting, the
+// session is never refreshed. It is called before continuing after a long
+// wait.
+func (p *printer) refreshSession(ctx context.Context) error {
+	p.transport.sessionMu.Lock()
+	timeout := p.transport.sessionTimeout
+	fetched := p.transport.sessionTimeoutFetched
+	idle := time.Since(p.transport.lastRequest)
+	p.transport.sessionMu.Unlock()
+
+	// logins so far (fetching the timeout may itself log in again)
+	logins := p.logins.Load()
+
+	if !fetched {
+		var err error
+		timeout, err = p.getSessionTimeout(ctx)
+		if errors.Is(err, ErrUnsupported) || errors.Is(err, errSessionTimeoutFieldNotFound) {
+			// no setting; don't look again
+			p.setSessionTimeout(0)
+		} else if err != nil {
+			return fmt.Errorf("printer: failed to get session timeout (%w)", err)
+		}
+	}
+
+	// leave a margin for clock granularity on the device
+	if timeout <= 0 || idle < timeout-10*time.Second {
+		return nil
+	}
+
+	err := p.loginSince(ctx, p.password, logins)
+	if err != nil {
+		return fmt.Errorf("printer: failed to log in again after session timeout (%w)", err)
+	}
+
+	return nil
+}
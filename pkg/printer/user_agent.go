@@ -0,0 +1,46 @@
+package printer
+
+import (
+	"runtime/debug"
+)
+
+const modulePath = "github.com/gregtwallace/brother-cert"
+
+// defaultUserAgent returns the User-Agent used if none is configured, e.g.
+// `brother-cert/v0.3.0` (the version is omitted if it isn't known, such as
+// in a development build)
+func defaultUserAgent() string {
+	userAgent := "brother-cert"
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return userAgent
+	}
+
+	// version of this module (whether it is the main module or a dependency)
+	version := ""
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			version = dep.Version
+		}
+	}
+
+	if version != "" && version != "(devel)" {
+		userAgent += "/" + version
+	}
+
+	return userAgent
+}
+
+// WithUserAgent sets the User-Agent header sent with every request, replacing
+// the Config's UserAgent (if any). The default identifies this package, e.g.
+// `brother-cert/v0.3.0`, since some network security appliances block Go's
+// default User-Agent.
+func WithUserAgent(userAgent string) Option {
+	return func(p *printer) {
+		p.transport.userAgent = userAgent
+	}
+}